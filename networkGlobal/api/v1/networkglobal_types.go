@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -29,15 +30,146 @@ type NetworkGlobalSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 	ID   string `json:"id,omitempty"`
 	Name string `json:"name,omitempty"`
+
+	// AddressSpaces represents the root IPv4 and IPv6 prefixes of the network.
+	// Subnets without a parent must lie within one of them.
+	// Top-level subnets are unrestricted if none are declared.
+	AddressSpaces []string `json:"addressSpaces,omitempty"`
+
+	// DeletionPolicy represents what happens to the subnets of the network when it is deleted.
+	// Block keeps the network until its subnets are gone, Cascade deletes them first.
+	// +kubebuilder:validation:Enum=Block;Cascade
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// DeletionPolicy represents how the deletion of a NetworkGlobal treats its subnets
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyBlock keeps the NetworkGlobal until its subnets are deleted
+	DeletionPolicyBlock DeletionPolicy = "Block"
+
+	// DeletionPolicyCascade deletes the subnets of the NetworkGlobal along with it
+	DeletionPolicyCascade DeletionPolicy = "Cascade"
+)
+
+// NetworkGlobalConditionType represents an aspect of the NetworkGlobal state
+type NetworkGlobalConditionType string
+
+const (
+	// NetworkGlobalReady is true when the NetworkGlobal is valid and not going away
+	NetworkGlobalReady NetworkGlobalConditionType = "Ready"
+
+	// NetworkGlobalValid is true when the spec of the NetworkGlobal is well-formed
+	NetworkGlobalValid NetworkGlobalConditionType = "Valid"
+
+	// NetworkGlobalExhausted is true when the top-level subnets take all of the address spaces
+	NetworkGlobalExhausted NetworkGlobalConditionType = "Exhausted"
+
+	// NetworkGlobalDeletionBlocked is true while subnets keep a deleted NetworkGlobal around
+	NetworkGlobalDeletionBlocked NetworkGlobalConditionType = "DeletionBlocked"
+)
+
+// NetworkGlobalCondition represents an observation of the NetworkGlobal state
+type NetworkGlobalCondition struct {
+	// Type represents the aspect the condition is about
+	Type NetworkGlobalConditionType `json:"type"`
+
+	// Status represents whether the condition holds, one of True, False or Unknown
+	Status metav1.ConditionStatus `json:"status"`
+
+	// Reason is a machine readable explanation of the status
+	Reason string `json:"reason,omitempty"`
+
+	// Message explains the status
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration represents the generation the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime represents when the status changed last
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// AddressUsage defines how much of the address spaces of one address family is taken
+type AddressUsage struct {
+	// Capacity represents the number of addresses in the address spaces of the family
+	Capacity resource.Quantity `json:"capacity,omitempty"`
+
+	// Used represents the number of these addresses taken by valid top-level subnets
+	Used resource.Quantity `json:"used,omitempty"`
+
+	// DisplayCapacity represents the capacity in a readable form, e.g. 2^96 for a /32 IPv6 space
+	DisplayCapacity string `json:"displayCapacity,omitempty"`
+
+	// DisplayUsed represents the used addresses in a readable form
+	DisplayUsed string `json:"displayUsed,omitempty"`
 }
 
 // NetworkGlobalStatus defines the observed state of NetworkGlobal
 type NetworkGlobalStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// IPv4 represents the usage of the IPv4 address spaces. The families are
+	// reported apart, as the IPv6 figures would swamp the IPv4 ones.
+	IPv4 AddressUsage `json:"ipv4,omitempty"`
+
+	// IPv6 represents the usage of the IPv6 address spaces
+	IPv6 AddressUsage `json:"ipv6,omitempty"`
+
+	// ObservedGeneration represents the generation the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represents the latest observations of the NetworkGlobal
+	Conditions []NetworkGlobalCondition `json:"conditions,omitempty"`
+}
+
+// Family returns the usage of the address family, IPv4 or IPv6.
+func (s *NetworkGlobalStatus) Family(family string) *AddressUsage {
+	if family == "IPv6" {
+		return &s.IPv6
+	}
+	return &s.IPv4
+}
+
+// GetCondition returns the condition of the given type, or nil if it isn't set.
+func (s *NetworkGlobalStatus) GetCondition(t NetworkGlobalConditionType) *NetworkGlobalCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition sets the condition, keeping the transition time if the status
+// stays the same. It reports whether the condition changed.
+func (s *NetworkGlobalStatus) SetCondition(condition NetworkGlobalCondition) bool {
+	existing := s.GetCondition(condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = metav1.Now()
+		s.Conditions = append(s.Conditions, condition)
+		return true
+	}
+	if existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+		return false
+	}
+	condition.LastTransitionTime = existing.LastTransitionTime
+	if existing.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	*existing = condition
+	return true
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ID",type=string,JSONPath=`.spec.id`
+// +kubebuilder:printcolumn:name="IPv4 Capacity",type=string,JSONPath=`.status.ipv4.displayCapacity`
+// +kubebuilder:printcolumn:name="IPv4 Used",type=string,JSONPath=`.status.ipv4.displayUsed`
+// +kubebuilder:printcolumn:name="IPv6 Capacity",type=string,JSONPath=`.status.ipv6.displayCapacity`,priority=1
+// +kubebuilder:printcolumn:name="IPv6 Used",type=string,JSONPath=`.status.ipv6.displayUsed`,priority=1
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // NetworkGlobal is the Schema for the networkglobals API
 type NetworkGlobal struct {
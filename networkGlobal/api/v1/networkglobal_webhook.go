@@ -0,0 +1,157 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// IDField is the cache field index NetworkGlobals are looked up with by their ID.
+const IDField = "spec.id"
+
+// log is for logging in this package.
+var networkgloballog = logf.Log.WithName("networkglobal-resource")
+
+// SetupWebhookWithManager registers the NetworkGlobal webhooks with the manager's
+// webhook server. The IDField index has to be registered on the manager.
+func (r *NetworkGlobal) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-core-core-gardener-cloud-v1-networkglobal",
+		&webhook.Admission{Handler: &NetworkGlobalValidator{}})
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-core-core-gardener-cloud-v1-networkglobal,mutating=false,failurePolicy=fail,groups=core.core.gardener.cloud,resources=networkglobals,verbs=create;update,versions=v1,name=vnetworkglobal.kb.io
+
+// +kubebuilder:object:generate=false
+
+// NetworkGlobalValidator rejects NetworkGlobals that are malformed or reuse the
+// ID of another NetworkGlobal in the namespace.
+type NetworkGlobalValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// Handle implements admission.Handler.
+func (v *NetworkGlobalValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	nGlobal := &NetworkGlobal{}
+	if err := v.decoder.Decode(req, nGlobal); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	networkgloballog.Info("validate", "name", nGlobal.Name, "operation", req.Operation)
+
+	var old *NetworkGlobal
+	if len(req.OldObject.Raw) > 0 {
+		old = &NetworkGlobal{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// Finalizer changes must pass while the object is going away.
+		if !nGlobal.DeletionTimestamp.IsZero() {
+			return admission.Allowed("")
+		}
+	}
+
+	errs := nGlobal.validateSpec(old)
+	if nGlobal.Spec.ID != "" {
+		others := &NetworkGlobalList{}
+		if err := v.client.List(ctx, others, client.InNamespace(nGlobal.Namespace), client.MatchingFields{IDField: nGlobal.Spec.ID}); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		for _, other := range others.Items {
+			if other.Name != nGlobal.Name {
+				errs = append(errs, field.Duplicate(field.NewPath("spec").Child("id"), nGlobal.Spec.ID))
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return admission.Denied(apierrors.NewInvalid(GroupVersion.WithKind("NetworkGlobal").GroupKind(), nGlobal.Name, errs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// InjectClient implements inject.Client.
+func (v *NetworkGlobalValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *NetworkGlobalValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Validate checks the spec of the NetworkGlobal on its own, and that the ID
+// didn't change if old is set. The uniqueness of the ID is left to the webhook.
+func (r *NetworkGlobal) Validate(old *NetworkGlobal) error {
+	if errs := r.validateSpec(old); len(errs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("NetworkGlobal").GroupKind(), r.Name, errs)
+	}
+	return nil
+}
+
+// validateSpec checks the spec, and that the ID didn't change if old is set.
+func (r *NetworkGlobal) validateSpec(old *NetworkGlobal) field.ErrorList {
+	var errs field.ErrorList
+	idPath := field.NewPath("spec").Child("id")
+
+	if r.Spec.ID == "" {
+		errs = append(errs, field.Required(idPath, ""))
+	}
+	if old != nil && old.Spec.ID != r.Spec.ID {
+		errs = append(errs, field.Invalid(idPath, r.Spec.ID, "field is immutable"))
+	}
+	return append(errs, validateAddressSpaces(field.NewPath("spec").Child("addressSpaces"), r.Spec.AddressSpaces)...)
+}
+
+// validateAddressSpaces checks that the address spaces are canonical CIDRs
+// that don't overlap each other.
+func validateAddressSpaces(path *field.Path, spaces []string) field.ErrorList {
+	var errs field.ErrorList
+	var nets []*net.IPNet
+	for i, space := range spaces {
+		_, ipNet, err := net.ParseCIDR(space)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), space, "must be a valid CIDR"))
+			continue
+		}
+		if ipNet.String() != space {
+			errs = append(errs, field.Invalid(path.Index(i), space, fmt.Sprintf("must be written as %s", ipNet)))
+			continue
+		}
+		for _, other := range nets {
+			if ipNet.Contains(other.IP) || other.Contains(ipNet.IP) {
+				errs = append(errs, field.Invalid(path.Index(i), space, fmt.Sprintf("overlaps %s", other)))
+			}
+		}
+		nets = append(nets, ipNet)
+	}
+	return errs
+}
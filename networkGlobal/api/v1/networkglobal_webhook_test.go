@@ -0,0 +1,161 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// indexedClient answers the IDField selector the way the field index of the
+// manager does, which the fake client ignores.
+type indexedClient struct {
+	client.Client
+}
+
+func (c indexedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	id, ok := "", false
+	if listOpts.FieldSelector != nil {
+		id, ok = listOpts.FieldSelector.RequiresExactMatch(IDField)
+	}
+	nGlobals, isNetworkGlobals := list.(*NetworkGlobalList)
+	if !ok || !isNetworkGlobals {
+		return nil
+	}
+
+	var matches []runtime.Object
+	for i := range nGlobals.Items {
+		if nGlobals.Items[i].Spec.ID == id {
+			matches = append(matches, &nGlobals.Items[i])
+		}
+	}
+	return meta.SetList(list, matches)
+}
+
+var _ = Describe("NetworkGlobal webhook", func() {
+	ctx := context.Background()
+	const namespace = "default"
+
+	var validator *NetworkGlobalValidator
+
+	networkGlobal := func(name, id string, addressSpaces ...string) *NetworkGlobal {
+		return &NetworkGlobal{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "NetworkGlobal"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       NetworkGlobalSpec{ID: id, AddressSpaces: addressSpaces},
+		}
+	}
+	handle := func(nGlobal, old *NetworkGlobal) admission.Response {
+		request := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object:    rawObject(nGlobal),
+		}}
+		if old != nil {
+			request.Operation = admissionv1beta1.Update
+			request.OldObject = rawObject(old)
+		}
+		return validator.Handle(ctx, request)
+	}
+
+	BeforeEach(func() {
+		Expect(AddToScheme(scheme.Scheme)).To(Succeed())
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		validator = &NetworkGlobalValidator{}
+		Expect(validator.InjectDecoder(decoder)).To(Succeed())
+		Expect(validator.InjectClient(indexedClient{fake.NewFakeClientWithScheme(scheme.Scheme,
+			networkGlobal("existing", "existing", "10.0.0.0/16"),
+			networkGlobal("other", "other", "10.1.0.0/16"),
+		)})).To(Succeed())
+	})
+
+	DescribeTable("validates new networkGlobals",
+		func(nGlobal *NetworkGlobal, field string) {
+			response := handle(nGlobal, nil)
+			if field == "" {
+				Expect(response.Allowed).To(BeTrue(), "denied: %s", response.Result.Reason)
+				return
+			}
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(ContainSubstring(field + ":"))
+		},
+		Entry("a valid networkGlobal", networkGlobal("new", "new", "10.2.0.0/16", "fd00::/48"), ""),
+		Entry("a networkGlobal without address spaces", networkGlobal("new", "new"), ""),
+		Entry("a missing ID", networkGlobal("new", ""), "spec.id"),
+		Entry("the ID of another networkGlobal", networkGlobal("new", "existing"), "spec.id"),
+		Entry("a malformed address space", networkGlobal("new", "new", "10.2.0.0/33"), "spec.addressSpaces[0]"),
+		Entry("an address space that isn't canonical", networkGlobal("new", "new", "10.2.0.1/16"), "spec.addressSpaces[0]"),
+		Entry("overlapping address spaces", networkGlobal("new", "new", "10.2.0.0/16", "10.2.128.0/17"), "spec.addressSpaces[1]"),
+	)
+
+	DescribeTable("validates updates",
+		func(mutate func(nGlobal *NetworkGlobal), field string) {
+			old := networkGlobal("existing", "existing", "10.0.0.0/16")
+			nGlobal := old.DeepCopy()
+			mutate(nGlobal)
+			response := handle(nGlobal, old)
+			if field == "" {
+				Expect(response.Allowed).To(BeTrue(), "denied: %s", response.Result.Reason)
+				return
+			}
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(ContainSubstring(field + ":"))
+		},
+		Entry("an added address space", func(n *NetworkGlobal) {
+			n.Spec.AddressSpaces = append(n.Spec.AddressSpaces, "fd00::/48")
+		}, ""),
+		Entry("a changed ID", func(n *NetworkGlobal) {
+			n.Spec.ID = "renamed"
+		}, "spec.id"),
+		Entry("a changed ID that another networkGlobal has", func(n *NetworkGlobal) {
+			n.Spec.ID = "other"
+		}, "spec.id"),
+		Entry("an overlapping address space", func(n *NetworkGlobal) {
+			n.Spec.AddressSpaces = append(n.Spec.AddressSpaces, "10.0.1.0/24")
+		}, "spec.addressSpaces[1]"),
+		Entry("a spec change while it is being deleted", func(n *NetworkGlobal) {
+			now := metav1.Now()
+			n.DeletionTimestamp = &now
+			n.Spec.AddressSpaces = []string{"10.0.0.0/33"}
+		}, ""),
+	)
+})
+
+// rawObject returns the object the way the API server hands it to a webhook.
+func rawObject(obj runtime.Object) runtime.RawExtension {
+	raw, err := json.Marshal(obj)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return runtime.RawExtension{Raw: raw}
+}
@@ -21,16 +21,33 @@ limitations under the License.
 package v1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressUsage) DeepCopyInto(out *AddressUsage) {
+	*out = *in
+	out.Capacity = in.Capacity.DeepCopy()
+	out.Used = in.Used.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressUsage.
+func (in *AddressUsage) DeepCopy() *AddressUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkGlobal) DeepCopyInto(out *NetworkGlobal) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkGlobal.
@@ -51,6 +68,22 @@ func (in *NetworkGlobal) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkGlobalCondition) DeepCopyInto(out *NetworkGlobalCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkGlobalCondition.
+func (in *NetworkGlobalCondition) DeepCopy() *NetworkGlobalCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkGlobalCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkGlobalList) DeepCopyInto(out *NetworkGlobalList) {
 	*out = *in
@@ -86,6 +119,11 @@ func (in *NetworkGlobalList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkGlobalSpec) DeepCopyInto(out *NetworkGlobalSpec) {
 	*out = *in
+	if in.AddressSpaces != nil {
+		in, out := &in.AddressSpaces, &out.AddressSpaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkGlobalSpec.
@@ -101,6 +139,15 @@ func (in *NetworkGlobalSpec) DeepCopy() *NetworkGlobalSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkGlobalStatus) DeepCopyInto(out *NetworkGlobalStatus) {
 	*out = *in
+	in.IPv4.DeepCopyInto(&out.IPv4)
+	in.IPv6.DeepCopyInto(&out.IPv6)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NetworkGlobalCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkGlobalStatus.
@@ -0,0 +1,36 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	v1 "gardener/networkGlobal/api/v1"
+)
+
+// SetupIndexes registers the cache field indexes the controller and the
+// webhook look NetworkGlobals up with.
+func SetupIndexes(mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(&v1.NetworkGlobal{}, v1.IDField, func(o runtime.Object) []string {
+		nGlobal := o.(*v1.NetworkGlobal)
+		if nGlobal.Spec.ID == "" {
+			return nil
+		}
+		return []string{nGlobal.Spec.ID}
+	})
+}
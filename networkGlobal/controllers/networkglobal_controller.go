@@ -22,9 +22,11 @@ import (
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -40,27 +42,26 @@ const (
 // NetworkGlobalReconciler reconciles a NetworkGlobal object
 type NetworkGlobalReconciler struct {
 	client.Client
-	Log           logr.Logger
-	Scheme        *runtime.Scheme
-	Manager       ctrl.Manager
-	NetworkGlobal *corev1.NetworkGlobal
-	Request       ctrl.Request
+	Recorder record.EventRecorder
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Manager  ctrl.Manager
 }
 
 // +kubebuilder:rbac:groups=core.core.gardener.cloud,resources=networkglobals,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.core.gardener.cloud,resources=networkglobals/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *NetworkGlobalReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
-	r.Request = req
 	log := r.Log.WithValues("networkglobal", req.NamespacedName)
 
 	reqLogger := r.Log.WithValues("Request.Name", req.Name)
 	reqLogger.Info("Reconciling NetworkGlobal")
 
-	r.NetworkGlobal = &corev1.NetworkGlobal{}
-
-	if err := r.Get(ctx, req.NamespacedName, r.NetworkGlobal); err != nil {
+	nGlobal := &corev1.NetworkGlobal{}
+	if err := r.Get(ctx, req.NamespacedName, nGlobal); err != nil {
 		log.Info("unable to fetch NetworkGlobal", "NetworkGlobal", req, "Error", err)
 		// we'll ignore not-found errors, since they can't be fixed by an immediate
 		// requeue (we'll need to wait for a new notification), and we can get them
@@ -69,34 +70,54 @@ func (r *NetworkGlobalReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 	}
 
 	// Add finalizer on the NetworkGlobal object if not added already.
-	if r.NetworkGlobal.DeletionTimestamp.IsZero() {
-		err := r.addNetworkGlobalFinalizer()
+	if nGlobal.DeletionTimestamp.IsZero() {
+		err := r.addNetworkGlobalFinalizer(ctx, nGlobal)
 		if err != nil {
-			log.Error(err, "Can't add the finalizer", "NetworkGlobal", r.NetworkGlobal.Name)
+			log.Error(err, "Can't add the finalizer", "NetworkGlobal", nGlobal.Name)
 			return ctrl.Result{}, err
 		}
 	}
 
 	// Deletion Flow
-	if !r.NetworkGlobal.DeletionTimestamp.IsZero() {
-		log.Info("Deleting the NetworkGlobal", "Name", r.NetworkGlobal.Name)
+	if !nGlobal.DeletionTimestamp.IsZero() {
+		log.Info("Deleting the NetworkGlobal", "Name", nGlobal.Name)
 
-		// Remove the finalizer
-		err := r.deleteNetworkGlobalFinalizers()
+		// Keep the finalizer while Subnets still reference the NetworkGlobal.
+		subnets, err := r.dependentSubnets(ctx, nGlobal)
 		if err != nil {
-			log.Error(err, "Couldn't delete the finalizer", "NetworkGlobal", r.NetworkGlobal.Name)
+			log.Error(err, "Couldn't list the dependent subnets", "NetworkGlobal", nGlobal.Name)
+			return ctrl.Result{}, err
+		}
+		if len(subnets) > 0 {
+			log.Info("Subnets still reference the NetworkGlobal", "Name", nGlobal.Name, "Subnets", len(subnets))
+			if err := r.blockDeletion(ctx, nGlobal, subnets); err != nil {
+				log.Error(err, "Couldn't block the deletion", "NetworkGlobal", nGlobal.Name)
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+
+		// Remove the finalizer
+		if err := r.deleteNetworkGlobalFinalizers(ctx, nGlobal); err != nil {
+			log.Error(err, "Couldn't delete the finalizer", "NetworkGlobal", nGlobal.Name)
 			return ctrl.Result{}, err
 		}
-		log.V(0).Info("Successfully deleted the NetworkGlobal", "Name", r.NetworkGlobal.Name)
+		log.V(0).Info("Successfully deleted the NetworkGlobal", "Name", nGlobal.Name)
 		return ctrl.Result{}, nil
 	}
 
+	if err := r.updateNetworkGlobalStatus(ctx, nGlobal); err != nil {
+		log.Error(err, "Couldn't update the status", "NetworkGlobal", nGlobal.Name)
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
-func (r *NetworkGlobalReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *NetworkGlobalReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.NetworkGlobal{}).
+		WithOptions(options).
 		Watches(&source.Kind{Type: &corev1.NetworkGlobal{}}, handler.Funcs{
 			CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
 				q.Add(reconcile.Request{NamespacedName: types.NamespacedName{
@@ -111,5 +132,8 @@ func (r *NetworkGlobalReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				}})
 			},
 		}).
+		Watches(&source.Kind{Type: newSubnet()}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.subnetNetworkGlobalRequests),
+		}).
 		Complete(r)
 }
@@ -0,0 +1,108 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "gardener/networkGlobal/api/v1"
+)
+
+// updateNetworkGlobalStatus records whether the spec of the NetworkGlobal is
+// valid and which generation the status was computed for. It leaves the usage
+// and its conditions to the subnet controller.
+func (r *NetworkGlobalReconciler) updateNetworkGlobalStatus(ctx context.Context, nGlobal *v1.NetworkGlobal) error {
+	status := nGlobal.Status.DeepCopy()
+
+	valid := v1.NetworkGlobalCondition{
+		Type:               v1.NetworkGlobalValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		ObservedGeneration: nGlobal.Generation,
+	}
+	ready := v1.NetworkGlobalCondition{
+		Type:               v1.NetworkGlobalReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		ObservedGeneration: nGlobal.Generation,
+	}
+	duplicate, err := r.olderNetworkGlobalWithID(ctx, nGlobal)
+	if err != nil {
+		return err
+	}
+	if err := nGlobal.Validate(nil); err != nil {
+		valid.Status, valid.Reason, valid.Message = metav1.ConditionFalse, "InvalidSpec", err.Error()
+		ready.Status, ready.Reason, ready.Message = metav1.ConditionFalse, "Invalid", err.Error()
+	} else if duplicate != nil {
+		message := fmt.Sprintf("networkGlobal %q already has the id %q", duplicate.Name, nGlobal.Spec.ID)
+		valid.Status, valid.Reason, valid.Message = metav1.ConditionFalse, "DuplicateID", message
+		ready.Status, ready.Reason, ready.Message = metav1.ConditionFalse, "Invalid", message
+	}
+	status.SetCondition(valid)
+	status.SetCondition(ready)
+	status.ObservedGeneration = nGlobal.Generation
+
+	if equality.Semantic.DeepEqual(*status, nGlobal.Status) {
+		return nil
+	}
+
+	clone := nGlobal.DeepCopy()
+	clone.Status = *status
+	if err := r.Status().Update(ctx, clone); err != nil {
+		return err
+	}
+	if old := nGlobal.Status.GetCondition(v1.NetworkGlobalValid); old == nil || old.Status != valid.Status {
+		if valid.Status == metav1.ConditionTrue {
+			r.Recorder.Event(nGlobal, corev1.EventTypeNormal, "Valid", "The spec is valid")
+		} else {
+			r.Recorder.Event(nGlobal, corev1.EventTypeWarning, valid.Reason, valid.Message)
+		}
+	}
+	nGlobal.Status = clone.Status
+	return nil
+}
+
+// olderNetworkGlobalWithID returns a NetworkGlobal created before the given
+// one that has the same ID, or nil if the ID is unique. The webhook rejects
+// duplicates, this catches the ones that slipped through concurrently.
+func (r *NetworkGlobalReconciler) olderNetworkGlobalWithID(ctx context.Context, nGlobal *v1.NetworkGlobal) (*v1.NetworkGlobal, error) {
+	if nGlobal.Spec.ID == "" {
+		return nil, nil
+	}
+	nGlobals := &v1.NetworkGlobalList{}
+	if err := r.List(ctx, nGlobals, client.InNamespace(nGlobal.Namespace), client.MatchingFields{v1.IDField: nGlobal.Spec.ID}); err != nil {
+		return nil, err
+	}
+	for i := range nGlobals.Items {
+		other := &nGlobals.Items[i]
+		if other.Name == nGlobal.Name {
+			continue
+		}
+		if other.CreationTimestamp.Before(&nGlobal.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&nGlobal.CreationTimestamp) && other.Name < nGlobal.Name) {
+			return other, nil
+		}
+	}
+	return nil, nil
+}
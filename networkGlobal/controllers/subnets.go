@@ -0,0 +1,165 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "gardener/networkGlobal/api/v1"
+)
+
+// The Subnet API lives in the subnet module, which depends on this one, so
+// Subnets are handled as unstructured objects here.
+var subnetGVK = schema.GroupVersionKind{Group: "core.gardener.cloud", Version: "v1", Kind: "Subnet"}
+
+// maxListedSubnets bounds the number of Subnet names put into a condition message.
+const maxListedSubnets = 10
+
+// newSubnet returns an empty unstructured Subnet.
+func newSubnet() *unstructured.Unstructured {
+	subnet := &unstructured.Unstructured{}
+	subnet.SetGroupVersionKind(subnetGVK)
+	return subnet
+}
+
+// subnetNetworkGlobalID returns the name of the NetworkGlobal the Subnet references.
+func subnetNetworkGlobalID(subnet *unstructured.Unstructured) string {
+	id, _, _ := unstructured.NestedString(subnet.Object, "spec", "networkGlobalID")
+	return id
+}
+
+// dependentSubnets returns the Subnets referencing the NetworkGlobal by its ID.
+func (r *NetworkGlobalReconciler) dependentSubnets(ctx context.Context, nGlobal *v1.NetworkGlobal) ([]unstructured.Unstructured, error) {
+	if nGlobal.Spec.ID == "" {
+		return nil, nil
+	}
+	subnets := &unstructured.UnstructuredList{}
+	subnets.SetGroupVersionKind(subnetGVK.GroupVersion().WithKind(subnetGVK.Kind + "List"))
+	if err := r.List(ctx, subnets, client.InNamespace(nGlobal.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var dependents []unstructured.Unstructured
+	for _, subnet := range subnets.Items {
+		if subnetNetworkGlobalID(&subnet) == nGlobal.Spec.ID {
+			dependents = append(dependents, subnet)
+		}
+	}
+	return dependents, nil
+}
+
+// blockDeletion records on the NetworkGlobal which Subnets keep it from being
+// deleted. With the Cascade policy it deletes those Subnets first.
+func (r *NetworkGlobalReconciler) blockDeletion(ctx context.Context, nGlobal *v1.NetworkGlobal, subnets []unstructured.Unstructured) error {
+	if nGlobal.Spec.DeletionPolicy == v1.DeletionPolicyCascade {
+		for i := range subnets {
+			subnet := &subnets[i]
+			if subnet.GetDeletionTimestamp() != nil {
+				continue
+			}
+			r.Log.Info("Deleting the Subnet of the NetworkGlobal", "NetworkGlobal", nGlobal.Name, "Subnet", subnet.GetName())
+			if err := r.Delete(ctx, subnet); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			r.Recorder.Eventf(nGlobal, corev1.EventTypeNormal, "DeletingSubnet", "Deleting subnet %q as the deletion policy is Cascade", subnet.GetName())
+		}
+	}
+
+	var names []string
+	for _, subnet := range subnets {
+		names = append(names, subnet.GetName())
+	}
+	message, blocked, changed := SetDeletionBlocked(&nGlobal.Status, nGlobal.Spec.DeletionPolicy, nGlobal.Generation, names)
+	if !changed {
+		return nil
+	}
+	if blocked {
+		r.Recorder.Event(nGlobal, corev1.EventTypeWarning, "DeletionBlocked", message)
+	}
+	return client.IgnoreNotFound(r.Status().Update(ctx, nGlobal))
+}
+
+// SetDeletionBlocked sets the conditions of a deleted NetworkGlobal whose
+// Subnets still exist. It returns the message of the conditions, whether the
+// DeletionBlocked condition has just been set and whether anything changed.
+// It is shared with the NetworkGlobals of the network.onmetal.de group, which
+// have the same status.
+func SetDeletionBlocked(status *v1.NetworkGlobalStatus, policy v1.DeletionPolicy, generation int64, subnets []string) (string, bool, bool) {
+	reason := "SubnetsExist"
+	if policy == v1.DeletionPolicyCascade {
+		reason = "DeletingSubnets"
+	}
+	names := subnets
+	if len(names) > maxListedSubnets {
+		names = append(names[:maxListedSubnets:maxListedSubnets], fmt.Sprintf("and %d more", len(subnets)-maxListedSubnets))
+	}
+
+	message := fmt.Sprintf("waiting for subnets %s to be deleted", strings.Join(names, ", "))
+	blocked := status.SetCondition(v1.NetworkGlobalCondition{
+		Type:               v1.NetworkGlobalDeletionBlocked,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+	notReady := status.SetCondition(v1.NetworkGlobalCondition{
+		Type:               v1.NetworkGlobalReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Deleting",
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+	return message, blocked, blocked || notReady
+}
+
+// subnetNetworkGlobalRequests maps a Subnet to a request for the NetworkGlobal
+// it references, whose deletion may be waiting for it.
+func (r *NetworkGlobalReconciler) subnetNetworkGlobalRequests(a handler.MapObject) []reconcile.Request {
+	subnet, ok := a.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	id := subnetNetworkGlobalID(subnet)
+	if id == "" {
+		return nil
+	}
+
+	nGlobals := &v1.NetworkGlobalList{}
+	if err := r.List(context.Background(), nGlobals, client.InNamespace(subnet.GetNamespace()), client.MatchingFields{v1.IDField: id}); err != nil {
+		r.Log.Error(err, "Couldn't look up the NetworkGlobal", "ID", id)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, nGlobal := range nGlobals.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+			Name:      nGlobal.Name,
+			Namespace: nGlobal.Namespace,
+		}})
+	}
+	return requests
+}
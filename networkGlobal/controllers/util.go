@@ -5,71 +5,45 @@ import (
 
 	v1 "gardener/networkGlobal/api/v1"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // addNetworkGlobalFinalizer adds the finalizer on the NetworkGlobal.
-func (r NetworkGlobalReconciler) addNetworkGlobalFinalizer() error {
-	ctx := context.Background()
-	nGlobal := &v1.NetworkGlobal{}
-
-	err := r.Get(ctx, r.Request.NamespacedName, nGlobal)
-	if err != nil {
-		return client.IgnoreNotFound(err)
-	}
-
-	clone := nGlobal.DeepCopy()
-
-	if finalizers := sets.NewString(clone.Finalizers...); !finalizers.Has(NetworkGlobalFinalizerName) {
-		r.Log.Info("Adding finalizer")
+func (r *NetworkGlobalReconciler) addNetworkGlobalFinalizer(ctx context.Context, nGlobal *v1.NetworkGlobal) error {
+	if finalizers := sets.NewString(nGlobal.Finalizers...); !finalizers.Has(NetworkGlobalFinalizerName) {
+		r.Log.Info("Adding finalizer", "NetworkGlobal", nGlobal.Name)
 		finalizers.Insert(NetworkGlobalFinalizerName)
-		err := r.updateNetworkGlobalFinalizers(finalizers.List())
-		if err != nil {
-			return client.IgnoreNotFound(err)
+		if err := r.updateNetworkGlobalFinalizers(ctx, nGlobal, finalizers.List()); err != nil {
+			return err
 		}
+		r.Recorder.Event(nGlobal, corev1.EventTypeNormal, "Created", "NetworkGlobal is managed by the networkGlobal controller")
 	}
 	return nil
 }
 
 // updateNetworkGlobalFinalizers updates the finalizer on the NetworkGlobal.
-func (r *NetworkGlobalReconciler) updateNetworkGlobalFinalizers(finalizers []string) error {
-	ctx := context.Background()
-	nGlobal := &v1.NetworkGlobal{}
-	err := r.Get(ctx, r.Request.NamespacedName, nGlobal)
-	if err != nil {
-		return client.IgnoreNotFound(err)
-	}
-
+func (r *NetworkGlobalReconciler) updateNetworkGlobalFinalizers(ctx context.Context, nGlobal *v1.NetworkGlobal, finalizers []string) error {
 	clone := nGlobal.DeepCopy()
 	clone.Finalizers = finalizers
 
-	err = r.Patch(ctx, clone, client.MergeFrom(nGlobal))
-	if err != nil {
+	if err := r.Patch(ctx, clone, client.MergeFrom(nGlobal)); err != nil {
 		return client.IgnoreNotFound(err)
 	}
 
-	r.NetworkGlobal = clone
+	*nGlobal = *clone
 	return nil
 }
 
 // deleteNetworkGlobalFinalizers deletes the finalizer from the NetworkGlobal.
-func (r *NetworkGlobalReconciler) deleteNetworkGlobalFinalizers() error {
-	ctx := context.Background()
-	nGlobal := &v1.NetworkGlobal{}
-	err := r.Get(ctx, r.Request.NamespacedName, nGlobal)
-	if err != nil {
-		return client.IgnoreNotFound(err)
-	}
-
-	clone := nGlobal.DeepCopy()
-
-	if finalizers := sets.NewString(clone.Finalizers...); finalizers.Has(NetworkGlobalFinalizerName) {
+func (r *NetworkGlobalReconciler) deleteNetworkGlobalFinalizers(ctx context.Context, nGlobal *v1.NetworkGlobal) error {
+	if finalizers := sets.NewString(nGlobal.Finalizers...); finalizers.Has(NetworkGlobalFinalizerName) {
 		finalizers.Delete(NetworkGlobalFinalizerName)
-		err := r.updateNetworkGlobalFinalizers(finalizers.List())
-		if err != nil {
-			return client.IgnoreNotFound(err)
+		if err := r.updateNetworkGlobalFinalizers(ctx, nGlobal, finalizers.List()); err != nil {
+			return err
 		}
+		r.Recorder.Event(nGlobal, corev1.EventTypeNormal, "Deleted", "Released the finalizer, the networkGlobal is going away")
 	}
 	return nil
 }
@@ -0,0 +1,210 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DualStackSubnetLabel is put on the Subnets of a DualStackSubnet and holds its name
+const DualStackSubnetLabel = "core.gardener.cloud/dual-stack-subnet"
+
+// DualStackFamilySpec defines the prefix of one address family of a DualStackSubnet
+type DualStackFamilySpec struct {
+	// CIDR represents the prefix of the family.
+	// It may be omitted if SubnetParentID and PrefixLength are set, in which
+	// case the next free block of the parent is carved out.
+	CIDR string `json:"cidr,omitempty"`
+
+	// PrefixLength represents the requested size of a carved prefix
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	PrefixLength int `json:"prefixLength,omitempty"`
+
+	// SubnetParentID represents the Subnet the prefix lies in.
+	// It holds the name of a Subnet in the same namespace.
+	SubnetParentID string `json:"subnetParentID,omitempty"`
+
+	// ReservedRanges represents address ranges of the prefix that are not handed out
+	ReservedRanges []AddressRange `json:"reservedRanges,omitempty"`
+
+	// DHCPPools represents address ranges of the prefix the DHCP servers lease to any client
+	DHCPPools []AddressRange `json:"dhcpPools,omitempty"`
+
+	// Gateway represents the gateway address of the prefix, the first usable host if omitted
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// DualStackSubnetSpec defines the desired state of DualStackSubnet
+type DualStackSubnetSpec struct {
+	// NetworkGlobalID represents the network both prefixes belong to.
+	// It holds the spec.id of a NetworkGlobal in the same namespace.
+	NetworkGlobalID string `json:"networkGlobalID,omitempty"`
+
+	// PartitionID represents the location of the L2 segment.
+	// It holds the name of a Partition in the same namespace.
+	PartitionID string `json:"partitionID,omitempty"`
+
+	// DNSServers represents the name servers of the segment, of both families
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// SearchDomains represents the DNS search domains of the segment
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// MTU represents the maximum transmission unit of the segment
+	// +kubebuilder:validation:Minimum=68
+	// +kubebuilder:validation:Maximum=65535
+	MTU int `json:"mtu,omitempty"`
+
+	// VLANID represents the VLAN of the segment
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	VLANID int `json:"vlanID,omitempty"`
+
+	// IPv4 represents the IPv4 prefix of the segment
+	IPv4 DualStackFamilySpec `json:"ipv4"`
+
+	// IPv6 represents the IPv6 prefix of the segment
+	IPv6 DualStackFamilySpec `json:"ipv6"`
+}
+
+// Family returns the spec of the address family.
+func (s *DualStackSubnetSpec) Family(family string) *DualStackFamilySpec {
+	if family == SubnetTypeIPv6 {
+		return &s.IPv6
+	}
+	return &s.IPv4
+}
+
+// SubnetSpec returns the spec of the Subnet holding the prefix of the address family.
+func (s *DualStackSubnetSpec) SubnetSpec(family string) SubnetSpec {
+	f := s.Family(family)
+	return SubnetSpec{
+		Type:            family,
+		CIDR:            f.CIDR,
+		PrefixLength:    f.PrefixLength,
+		NetworkGlobalID: s.NetworkGlobalID,
+		PartitionID:     s.PartitionID,
+		SubnetParentID:  f.SubnetParentID,
+		ReservedRanges:  f.ReservedRanges,
+		DHCPPools:       f.DHCPPools,
+		Gateway:         f.Gateway,
+		DNSServers:      s.DNSServers,
+		SearchDomains:   s.SearchDomains,
+		MTU:             s.MTU,
+		VLANID:          s.VLANID,
+	}
+}
+
+// DualStackSubnetFamilies lists the address families of a DualStackSubnet in the order they are handled.
+var DualStackSubnetFamilies = []string{SubnetTypeIPv4, SubnetTypeIPv6}
+
+// DualStackSubnetMemberName returns the name of the Subnet holding the prefix
+// of the address family of the DualStackSubnet.
+func DualStackSubnetMemberName(name, family string) string {
+	return name + "-" + strings.ToLower(family)
+}
+
+// DualStackFamilyStatus defines the observed state of one address family of a DualStackSubnet
+type DualStackFamilyStatus struct {
+	// Subnet represents the name of the Subnet holding the prefix
+	Subnet string `json:"subnet,omitempty"`
+
+	// CIDR represents the prefix, also if it was carved out of the parent
+	CIDR string `json:"cidr,omitempty"`
+
+	// State represents whether the prefix fits into the address plan
+	State SubnetState `json:"state,omitempty"`
+
+	// Gateway represents the effective gateway address of the prefix
+	Gateway string `json:"gateway,omitempty"`
+
+	// Capacity represents the capacity of the prefix
+	Capacity resource.Quantity `json:"capacity,omitempty"`
+
+	// CapacityLeft represents the available capacity of the prefix
+	CapacityLeft resource.Quantity `json:"capacityLeft,omitempty"`
+
+	// DisplayCapacity represents the capacity in a readable form
+	DisplayCapacity string `json:"displayCapacity,omitempty"`
+
+	// DisplayCapacityLeft represents the available capacity in a readable form
+	DisplayCapacityLeft string `json:"displayCapacityLeft,omitempty"`
+}
+
+// DualStackSubnetStatus defines the observed state of DualStackSubnet
+type DualStackSubnetStatus struct {
+	// IPv4 represents the state of the IPv4 prefix
+	IPv4 DualStackFamilyStatus `json:"ipv4,omitempty"`
+
+	// IPv6 represents the state of the IPv6 prefix
+	IPv6 DualStackFamilyStatus `json:"ipv6,omitempty"`
+
+	// State represents whether both prefixes fit into the address plan
+	State SubnetState `json:"state,omitempty"`
+
+	// Reason is a machine readable explanation of a Pending or Invalid state
+	Reason string `json:"reason,omitempty"`
+
+	// Message explains why the dual-stack subnet is pending or invalid
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration represents the generation the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// Family returns the status of the address family.
+func (s *DualStackSubnetStatus) Family(family string) *DualStackFamilyStatus {
+	if family == SubnetTypeIPv6 {
+		return &s.IPv6
+	}
+	return &s.IPv4
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="IPv4",type=string,JSONPath=`.status.ipv4.cidr`
+// +kubebuilder:printcolumn:name="IPv6",type=string,JSONPath=`.status.ipv6.cidr`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DualStackSubnet is the Schema for the dualstacksubnets API. It pairs an
+// IPv4 and an IPv6 prefix of the same L2 segment, each held by a Subnet the
+// controller manages.
+type DualStackSubnet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DualStackSubnetSpec   `json:"spec,omitempty"`
+	Status DualStackSubnetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DualStackSubnetList contains a list of DualStackSubnet
+type DualStackSubnetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DualStackSubnet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DualStackSubnet{}, &DualStackSubnetList{})
+}
@@ -0,0 +1,119 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"gardener/subnet/ipam"
+)
+
+// log is for logging in this package.
+var dualstacksubnetlog = logf.Log.WithName("dualstacksubnet-resource")
+
+// SetupWebhookWithManager registers the DualStackSubnet webhook with the manager.
+func (r *DualStackSubnet) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-core-gardener-cloud-v1-dualstacksubnet,mutating=false,failurePolicy=fail,groups=core.gardener.cloud,resources=dualstacksubnets,verbs=create;update,versions=v1,name=vdualstacksubnet.kb.io
+
+var _ webhook.Validator = &DualStackSubnet{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *DualStackSubnet) ValidateCreate() error {
+	dualstacksubnetlog.Info("validate create", "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *DualStackSubnet) ValidateUpdate(old runtime.Object) error {
+	dualstacksubnetlog.Info("validate update", "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *DualStackSubnet) ValidateDelete() error {
+	return nil
+}
+
+// validate checks that each prefix is of its address family and the shared
+// network settings. The references are checked by the Subnet webhook once the
+// Subnets of the prefixes are created.
+func (r *DualStackSubnet) validate() error {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if r.Spec.NetworkGlobalID == "" {
+		errs = append(errs, field.Required(specPath.Child("networkGlobalID"), ""))
+	}
+	for _, family := range DualStackSubnetFamilies {
+		errs = append(errs, r.Spec.Family(family).validate(specPath.Child(strings.ToLower(family)), family)...)
+	}
+	// The settings are shared by both families, so the MTU has to suit IPv6.
+	errs = append(errs, validateNetworkSettings(specPath, r.Spec.DNSServers, r.Spec.SearchDomains, r.Spec.MTU, SubnetTypeIPv6)...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("DualStackSubnet").GroupKind(), r.Name, errs)
+}
+
+// validate checks the prefix of the address family.
+func (s *DualStackFamilySpec) validate(path *field.Path, family string) field.ErrorList {
+	var errs field.ErrorList
+	cidrPath := path.Child("cidr")
+	if s.CIDR == "" {
+		if s.SubnetParentID == "" || s.PrefixLength == 0 {
+			errs = append(errs, field.Required(cidrPath, "unless subnetParentID and prefixLength are set"))
+		}
+		errs = append(errs, validateReservedRanges(path.Child("reservedRanges"), s.ReservedRanges, family, nil)...)
+		errs = append(errs, validateReservedRanges(path.Child("dhcpPools"), s.DHCPPools, family, nil)...)
+		return append(errs, validateGateway(path.Child("gateway"), s.Gateway, family, nil)...)
+	}
+	ipNet, err := ipam.ParseCIDR(s.CIDR)
+	if err != nil {
+		return append(errs, field.Invalid(cidrPath, s.CIDR, "must be a valid CIDR"))
+	}
+	if ipam.Family(ipNet) == family {
+		errs = append(errs, validateReservedRanges(path.Child("reservedRanges"), s.ReservedRanges, family, ipNet)...)
+		errs = append(errs, validateReservedRanges(path.Child("dhcpPools"), s.DHCPPools, family, ipNet)...)
+		errs = append(errs, validateGateway(path.Child("gateway"), s.Gateway, family, ipNet)...)
+		errs = append(errs, validatePoolsClearOfGateway(path.Child("dhcpPools"), s.DHCPPools, s.Gateway, ipNet)...)
+	}
+	if ipNet.String() != s.CIDR {
+		errs = append(errs, field.Invalid(cidrPath, s.CIDR, fmt.Sprintf("must be written as %s", ipNet)))
+	}
+	if ipam.Family(ipNet) != family {
+		errs = append(errs, field.Invalid(cidrPath, s.CIDR, fmt.Sprintf("must be an %s CIDR", family)))
+	}
+	if ones, _ := ipNet.Mask.Size(); s.PrefixLength != 0 && s.PrefixLength != ones {
+		errs = append(errs, field.Invalid(path.Child("prefixLength"), s.PrefixLength, fmt.Sprintf("doesn't match the /%d CIDR", ones)))
+	}
+	return errs
+}
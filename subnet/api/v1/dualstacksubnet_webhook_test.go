@@ -0,0 +1,84 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("DualStackSubnet webhook", func() {
+	validDualStackSubnet := func() *DualStackSubnet {
+		return &DualStackSubnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "segment", Namespace: "default"},
+			Spec: DualStackSubnetSpec{
+				NetworkGlobalID: "net",
+				IPv4:            DualStackFamilySpec{CIDR: "10.0.0.0/24"},
+				IPv6:            DualStackFamilySpec{CIDR: "fd00::/64"},
+			},
+		}
+	}
+
+	DescribeTable("validates the spec",
+		func(mutate func(*DualStackSubnetSpec), fields ...string) {
+			dualStack := validDualStackSubnet()
+			mutate(&dualStack.Spec)
+			Expect(invalidFields(dualStack.ValidateCreate())).To(ConsistOf(fields))
+			Expect(invalidFields(dualStack.ValidateUpdate(validDualStackSubnet()))).To(ConsistOf(fields))
+		},
+		Entry("a valid segment", func(s *DualStackSubnetSpec) {}),
+		Entry("carved prefixes", func(s *DualStackSubnetSpec) {
+			s.IPv4 = DualStackFamilySpec{SubnetParentID: "parent-v4", PrefixLength: 26}
+			s.IPv6 = DualStackFamilySpec{SubnetParentID: "parent-v6", PrefixLength: 64}
+		}),
+		Entry("a missing networkGlobalID", func(s *DualStackSubnetSpec) {
+			s.NetworkGlobalID = ""
+		}, "spec.networkGlobalID"),
+		Entry("a malformed CIDR", func(s *DualStackSubnetSpec) {
+			s.IPv4.CIDR = "10.0.0.0/33"
+		}, "spec.ipv4.cidr"),
+		Entry("a CIDR of the other family", func(s *DualStackSubnetSpec) {
+			s.IPv4.CIDR = "fd00:1::/64"
+		}, "spec.ipv4.cidr"),
+		Entry("a CIDR that isn't canonical", func(s *DualStackSubnetSpec) {
+			s.IPv6.CIDR = "fd00::1/64"
+		}, "spec.ipv6.cidr"),
+		Entry("a prefix length that doesn't match the CIDR", func(s *DualStackSubnetSpec) {
+			s.IPv4.PrefixLength = 26
+		}, "spec.ipv4.prefixLength"),
+		Entry("neither a CIDR nor a prefix length", func(s *DualStackSubnetSpec) {
+			s.IPv6 = DualStackFamilySpec{SubnetParentID: "parent-v6"}
+		}, "spec.ipv6.cidr"),
+		Entry("a gateway of the other family", func(s *DualStackSubnetSpec) {
+			s.IPv6.Gateway = "10.0.0.1"
+		}, "spec.ipv6.gateway"),
+		Entry("a reserved range outside the CIDR", func(s *DualStackSubnetSpec) {
+			s.IPv4.ReservedRanges = []AddressRange{{Start: "10.0.1.1", End: "10.0.1.9"}}
+		}, "spec.ipv4.reservedRanges[0]"),
+		Entry("a DHCP pool holding the gateway", func(s *DualStackSubnetSpec) {
+			s.IPv4.DHCPPools = []AddressRange{{Start: "10.0.0.1", End: "10.0.0.99"}}
+		}, "spec.ipv4.dhcpPools[0]"),
+		Entry("an MTU too small for IPv6", func(s *DualStackSubnetSpec) {
+			s.MTU = 1000
+		}, "spec.mtu"),
+		Entry("a malformed DNS server", func(s *DualStackSubnetSpec) {
+			s.DNSServers = []string{"10.0.0.300"}
+		}, "spec.dnsServers[0]"),
+	)
+})
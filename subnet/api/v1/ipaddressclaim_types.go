@@ -0,0 +1,115 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IPAddressClaimSpec defines the desired state of IPAddressClaim
+type IPAddressClaimSpec struct {
+	// SubnetID represents the subnet the address is taken from.
+	// It holds the name of the Subnet in the same namespace.
+	SubnetID string `json:"subnetID,omitempty"`
+
+	// DualStackSubnetID represents the dual-stack subnet a pair of addresses,
+	// one of each family, is taken from instead.
+	// It holds the name of the DualStackSubnet in the same namespace.
+	DualStackSubnetID string `json:"dualStackSubnetID,omitempty"`
+
+	// Address requests a specific address of the subnet, any free address is handed out if omitted.
+	// For a dual-stack subnet it requests the address of its family.
+	Address string `json:"address,omitempty"`
+
+	// HWAddress represents the MAC address of the host the address is for.
+	// Bound claims with a hardware address become DHCP reservations.
+	// +kubebuilder:validation:Pattern=`^([0-9a-fA-F]{2}:){5}[0-9a-fA-F]{2}$`
+	HWAddress string `json:"hwAddress,omitempty"`
+}
+
+// IPAddressClaimState represents whether the claim holds an address
+type IPAddressClaimState string
+
+const (
+	// IPAddressClaimStateBound means the address in the status is reserved for the claim
+	IPAddressClaimStateBound IPAddressClaimState = "Bound"
+
+	// IPAddressClaimStatePending means no address could be handed out yet
+	IPAddressClaimStatePending IPAddressClaimState = "Pending"
+
+	// IPAddressClaimStateInvalid means the requested address can never be handed out
+	IPAddressClaimStateInvalid IPAddressClaimState = "Invalid"
+)
+
+// Reasons explaining why a claim is Pending or Invalid
+const (
+	IPAddressClaimReasonSubnetNotFound     = "SubnetNotFound"
+	IPAddressClaimReasonNoSubnet           = "NoSubnet"
+	IPAddressClaimReasonSubnetNotValid     = "SubnetNotValid"
+	IPAddressClaimReasonSubnetExhausted    = "SubnetExhausted"
+	IPAddressClaimReasonAddressInUse       = "AddressInUse"
+	IPAddressClaimReasonAddressOutOfSubnet = "AddressOutOfSubnet"
+	IPAddressClaimReasonAddressReserved    = "AddressReserved"
+)
+
+// IPAddressClaimStatus defines the observed state of IPAddressClaim
+type IPAddressClaimStatus struct {
+	// Address represents the address handed out to the claim,
+	// the IPv4 address for a dual-stack subnet
+	Address string `json:"address,omitempty"`
+
+	// IPv6Address represents the IPv6 address handed out from a dual-stack subnet
+	IPv6Address string `json:"ipv6Address,omitempty"`
+
+	// State represents whether the claim holds an address
+	State IPAddressClaimState `json:"state,omitempty"`
+
+	// Reason is a machine readable explanation of a Pending or Invalid state
+	Reason string `json:"reason,omitempty"`
+
+	// Message explains why the claim is pending or invalid
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Subnet",type=string,JSONPath=`.spec.subnetID`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.address`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// IPAddressClaim is the Schema for the ipaddressclaims API
+type IPAddressClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IPAddressClaimSpec   `json:"spec,omitempty"`
+	Status IPAddressClaimStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IPAddressClaimList contains a list of IPAddressClaim
+type IPAddressClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPAddressClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPAddressClaim{}, &IPAddressClaimList{})
+}
@@ -0,0 +1,104 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var ipaddressclaimlog = logf.Log.WithName("ipaddressclaim-resource")
+
+// SetupWebhookWithManager registers the IPAddressClaim webhook with the manager.
+func (r *IPAddressClaim) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-core-gardener-cloud-v1-ipaddressclaim,mutating=false,failurePolicy=fail,groups=core.gardener.cloud,resources=ipaddressclaims,verbs=create;update,versions=v1,name=vipaddressclaim.kb.io
+
+var _ webhook.Validator = &IPAddressClaim{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *IPAddressClaim) ValidateCreate() error {
+	ipaddressclaimlog.Info("validate create", "name", r.Name)
+
+	return r.toError(r.Spec.validate(field.NewPath("spec")))
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Once the claim is bound, the subnet and the address it names can't change
+// any more, or the address would be counted against the wrong subnet.
+func (r *IPAddressClaim) ValidateUpdate(old runtime.Object) error {
+	ipaddressclaimlog.Info("validate update", "name", r.Name)
+
+	specPath := field.NewPath("spec")
+	errs := r.Spec.validate(specPath)
+	if oldClaim, ok := old.(*IPAddressClaim); ok && oldClaim.Status.State == IPAddressClaimStateBound {
+		for _, f := range []struct {
+			name     string
+			old, new string
+		}{
+			{"subnetID", oldClaim.Spec.SubnetID, r.Spec.SubnetID},
+			{"dualStackSubnetID", oldClaim.Spec.DualStackSubnetID, r.Spec.DualStackSubnetID},
+			{"address", oldClaim.Spec.Address, r.Spec.Address},
+		} {
+			if f.old != f.new {
+				errs = append(errs, field.Invalid(specPath.Child(f.name), f.new, "is immutable once the claim is bound"))
+			}
+		}
+	}
+	return r.toError(errs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *IPAddressClaim) ValidateDelete() error {
+	return nil
+}
+
+// validate checks that the claim names exactly one subnet and that a
+// requested address is well formed.
+func (s *IPAddressClaimSpec) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case s.SubnetID == "" && s.DualStackSubnetID == "":
+		errs = append(errs, field.Required(path.Child("subnetID"), "unless dualStackSubnetID is set"))
+	case s.SubnetID != "" && s.DualStackSubnetID != "":
+		errs = append(errs, field.Invalid(path.Child("dualStackSubnetID"), s.DualStackSubnetID,
+			fmt.Sprintf("must be empty as subnetID %q is set", s.SubnetID)))
+	}
+	if s.Address != "" && net.ParseIP(s.Address) == nil {
+		errs = append(errs, field.Invalid(path.Child("address"), s.Address, "must be a valid IP address"))
+	}
+	return errs
+}
+
+func (r *IPAddressClaim) toError(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("IPAddressClaim").GroupKind(), r.Name, errs)
+}
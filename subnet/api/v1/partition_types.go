@@ -0,0 +1,122 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PartitionSpec defines the desired state of Partition
+type PartitionSpec struct {
+	// Description represents what the partition is about
+	Description string `json:"description,omitempty"`
+
+	// Region represents the region the physical location belongs to
+	Region string `json:"region,omitempty"`
+
+	// AddressFamilies represents the address families available in the partition, all if omitted
+	AddressFamilies []AddressFamily `json:"addressFamilies,omitempty"`
+
+	// ReservedRanges represents CIDRs no subnet of the partition may overlap
+	ReservedRanges []string `json:"reservedRanges,omitempty"`
+}
+
+// AddressFamily represents IPv4 or IPv6
+// +kubebuilder:validation:Enum=IPv4;IPv6
+type AddressFamily string
+
+// PartitionAddressUsage defines how many addresses of one address family the partition offers
+type PartitionAddressUsage struct {
+	// Capacity represents the number of usable addresses of the outermost valid subnets of the family
+	Capacity resource.Quantity `json:"capacity,omitempty"`
+
+	// Used represents how many of these addresses are taken by child subnets or address claims
+	Used resource.Quantity `json:"used,omitempty"`
+
+	// DisplayCapacity represents the capacity in a readable form
+	DisplayCapacity string `json:"displayCapacity,omitempty"`
+
+	// DisplayUsed represents the used addresses in a readable form
+	DisplayUsed string `json:"displayUsed,omitempty"`
+}
+
+// PartitionStatus defines the observed state of Partition
+type PartitionStatus struct {
+	// Subnets represents the number of subnets located in the partition
+	Subnets int `json:"subnets,omitempty"`
+
+	// IPv4 represents the usage of the IPv4 subnets in the partition. The
+	// families are reported apart, as the IPv6 figures would swamp the IPv4 ones.
+	IPv4 PartitionAddressUsage `json:"ipv4,omitempty"`
+
+	// IPv6 represents the usage of the IPv6 subnets in the partition
+	IPv6 PartitionAddressUsage `json:"ipv6,omitempty"`
+}
+
+// Family returns the usage of the address family.
+func (s *PartitionStatus) Family(family string) *PartitionAddressUsage {
+	if family == SubnetTypeIPv6 {
+		return &s.IPv6
+	}
+	return &s.IPv4
+}
+
+// AllowsFamily reports whether subnets of the family may be located in the partition.
+func (s *PartitionSpec) AllowsFamily(family string) bool {
+	if len(s.AddressFamilies) == 0 {
+		return true
+	}
+	for _, f := range s.AddressFamilies {
+		if string(f) == family {
+			return true
+		}
+	}
+	return false
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Region",type=string,JSONPath=`.spec.region`
+// +kubebuilder:printcolumn:name="Subnets",type=integer,JSONPath=`.status.subnets`
+// +kubebuilder:printcolumn:name="IPv4 Capacity",type=string,JSONPath=`.status.ipv4.displayCapacity`
+// +kubebuilder:printcolumn:name="IPv4 Used",type=string,JSONPath=`.status.ipv4.displayUsed`
+// +kubebuilder:printcolumn:name="IPv6 Capacity",type=string,JSONPath=`.status.ipv6.displayCapacity`,priority=1
+// +kubebuilder:printcolumn:name="IPv6 Used",type=string,JSONPath=`.status.ipv6.displayUsed`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Partition is the Schema for the partitions API
+type Partition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PartitionSpec   `json:"spec,omitempty"`
+	Status PartitionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PartitionList contains a list of Partition
+type PartitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Partition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Partition{}, &PartitionList{})
+}
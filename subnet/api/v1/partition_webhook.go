@@ -0,0 +1,85 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"gardener/subnet/ipam"
+)
+
+// log is for logging in this package.
+var partitionlog = logf.Log.WithName("partition-resource")
+
+// SetupWebhookWithManager registers the Partition webhook with the manager.
+func (r *Partition) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-core-gardener-cloud-v1-partition,mutating=false,failurePolicy=fail,groups=core.gardener.cloud,resources=partitions,verbs=create;update,versions=v1,name=vpartition.kb.io
+
+var _ webhook.Validator = &Partition{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Partition) ValidateCreate() error {
+	partitionlog.Info("validate create", "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Partition) ValidateUpdate(old runtime.Object) error {
+	partitionlog.Info("validate update", "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Partition) ValidateDelete() error {
+	return nil
+}
+
+// validate checks that the reserved ranges are canonical CIDRs.
+func (r *Partition) validate() error {
+	var errs field.ErrorList
+	rangesPath := field.NewPath("spec").Child("reservedRanges")
+
+	for i, reserved := range r.Spec.ReservedRanges {
+		ipNet, err := ipam.ParseCIDR(reserved)
+		if err != nil {
+			errs = append(errs, field.Invalid(rangesPath.Index(i), reserved, "must be a valid CIDR"))
+			continue
+		}
+		if ipNet.String() != reserved {
+			errs = append(errs, field.Invalid(rangesPath.Index(i), reserved, fmt.Sprintf("must be written as %s", ipNet)))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Partition").GroupKind(), r.Name, errs)
+}
@@ -17,43 +17,294 @@ limitations under the License.
 package v1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+const (
+	// SubnetTypeIPv4 marks a subnet of IPv4 addresses
+	SubnetTypeIPv4 = "IPv4"
+
+	// SubnetTypeIPv6 marks a subnet of IPv6 addresses
+	SubnetTypeIPv6 = "IPv6"
+)
+
 // SubnetSpec defines the desired state of Subnet
 type SubnetSpec struct {
-	// ID represents the subnet id
+	// ID represents the subnet id, generated when omitted
 	ID string `json:"ID,omitempty"`
 
-	// Type represents whether it is an IPv4 or IPv6
+	// Type represents whether it is an IPv4 or IPv6, derived from the CIDR when omitted
+	// +kubebuilder:validation:Enum=IPv4;IPv6
 	Type string `json:"type,omitempty"`
 
-	// CIDR represents the Ip Adress Range
+	// CIDR represents the Ip Adress Range.
+	// It may be omitted on a child subnet that sets PrefixLength, in which
+	// case the controller carves the next free block out of the parent.
 	CIDR string `json:"cidr,omitempty"`
 
-	// NetworkGlobal represents the network which belongs to the subnet
+	// PrefixLength represents the requested size of a carved child subnet
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	PrefixLength int `json:"prefixLength,omitempty"`
+
+	// NetworkGlobal represents the network which belongs to the subnet.
+	// It holds the spec.id of a NetworkGlobal in the same namespace.
 	NetworkGlobalID string `json:"networkGlobalID,omitempty"`
 
-	// PartiionID represents the location of the physical servers
+	// PartiionID represents the location of the physical servers.
+	// It holds the name of a Partition in the same namespace.
 	PartitionID string `json:"partitionID,omitempty"`
 
-	// SubnetParentID represents the parent of the subnet if present
+	// SubnetParentID represents the parent of the subnet if present.
+	// It holds the name of the parent Subnet in the same namespace.
 	SubnetParentID string `json:"subnetParentID,omitempty"`
+
+	// ReservedRanges represents address ranges of the subnet that are neither
+	// handed out to claims nor carved into child subnets, e.g. for routers.
+	// The IPv4 network and broadcast addresses and the IPv6 subnet-router
+	// anycast address are always reserved.
+	ReservedRanges []AddressRange `json:"reservedRanges,omitempty"`
+
+	// DHCPPools represents address ranges of the subnet the DHCP servers lease
+	// to any client. Like reserved ranges they are neither handed out to claims
+	// nor carved into child subnets. Without pools only claims get addresses.
+	DHCPPools []AddressRange `json:"dhcpPools,omitempty"`
+
+	// Gateway represents the gateway address of the subnet.
+	// It defaults to the first usable host and is never handed out to claims.
+	Gateway string `json:"gateway,omitempty"`
+
+	// DNSServers represents the addresses of the name servers, inherited from
+	// the parent subnet when omitted
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// SearchDomains represents the DNS search domains, inherited from the
+	// parent subnet when omitted
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// MTU represents the maximum transmission unit, inherited from the parent
+	// subnet when omitted
+	// +kubebuilder:validation:Minimum=68
+	// +kubebuilder:validation:Maximum=65535
+	MTU int `json:"mtu,omitempty"`
+
+	// VLANID represents the VLAN the subnet is attached to, inherited from the
+	// parent subnet when omitted
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	VLANID int `json:"vlanID,omitempty"`
+}
+
+// AddressRange defines an inclusive range of addresses
+type AddressRange struct {
+	// Start represents the first address of the range
+	Start string `json:"start"`
+
+	// End represents the last address of the range, Start if omitted
+	End string `json:"end,omitempty"`
+}
+
+// ExcludedRanges returns the reserved ranges and the DHCP pools of the subnet,
+// the ranges the address management keeps out of claims and children.
+func (s *SubnetSpec) ExcludedRanges() []AddressRange {
+	return append(append([]AddressRange(nil), s.ReservedRanges...), s.DHCPPools...)
+}
+
+// String returns the range as start-end, or start alone for a single address.
+func (r AddressRange) String() string {
+	if r.End == "" {
+		return r.Start
+	}
+	return r.Start + "-" + r.End
+}
+
+// Cache field indexes Subnets are looked up with by the objects they reference
+const (
+	NetworkGlobalIDField = "spec.networkGlobalID"
+	SubnetParentIDField  = "spec.subnetParentID"
+	PartitionIDField     = "spec.partitionID"
+)
+
+// SubnetState represents whether the subnet fits into the address plan
+type SubnetState string
+
+const (
+	// SubnetStateValid means the subnet lies within its parent and doesn't overlap its siblings
+	SubnetStateValid SubnetState = "Valid"
+
+	// SubnetStateInvalid means the subnet escapes its parent or collides with a sibling
+	SubnetStateInvalid SubnetState = "Invalid"
+
+	// SubnetStatePending means an object the subnet references doesn't exist yet
+	SubnetStatePending SubnetState = "Pending"
+)
+
+// Reasons explaining why a subnet is Pending or Invalid
+const (
+	SubnetReasonNetworkGlobalNotFound = "NetworkGlobalNotFound"
+	SubnetReasonParentNotFound        = "ParentNotFound"
+	SubnetReasonInvalidCIDR           = "InvalidCIDR"
+	SubnetReasonNetworkGlobalMismatch = "NetworkGlobalMismatch"
+	SubnetReasonOutsideParent         = "OutsideParent"
+	SubnetReasonOutsideAddressSpace   = "OutsideAddressSpace"
+	SubnetReasonOverlap               = "Overlap"
+	SubnetReasonParentNotValid        = "ParentNotValid"
+	SubnetReasonParentExhausted       = "ParentExhausted"
+	SubnetReasonPartitionNotFound     = "PartitionNotFound"
+	SubnetReasonFamilyNotAvailable    = "FamilyNotAvailable"
+	SubnetReasonReservedRange         = "ReservedRange"
+	SubnetReasonParentReservedRange   = "ParentReservedRange"
+	SubnetReasonInvalidGateway        = "InvalidGateway"
+	SubnetReasonNetworkGlobalGone     = "NetworkGlobalGone"
+)
+
+// SubnetConditionType represents an aspect of the Subnet state
+type SubnetConditionType string
+
+const (
+	// SubnetReady is true when the subnet is valid and addresses can be taken from it
+	SubnetReady SubnetConditionType = "Ready"
+
+	// SubnetValid is true when the subnet fits into the address plan
+	SubnetValid SubnetConditionType = "Valid"
+
+	// SubnetParentResolved is true when the subnet has no parent or the parent exists
+	SubnetParentResolved SubnetConditionType = "ParentResolved"
+
+	// SubnetNetworkGlobalResolved is true when the referenced NetworkGlobal exists
+	SubnetNetworkGlobalResolved SubnetConditionType = "NetworkGlobalResolved"
+
+	// SubnetExhausted is true when no address of the subnet is left
+	SubnetExhausted SubnetConditionType = "Exhausted"
+
+	// SubnetDeletionBlocked is true while children or address claims keep a deleted Subnet around
+	SubnetDeletionBlocked SubnetConditionType = "DeletionBlocked"
+
+	// SubnetOrphaned is true when the NetworkGlobal the subnet was created in has disappeared
+	SubnetOrphaned SubnetConditionType = "Orphaned"
+
+	// SubnetGatewayConflict is true when an address claim holds the gateway
+	// address, as claims bound before the gateway was kept out of them may
+	SubnetGatewayConflict SubnetConditionType = "GatewayConflict"
+)
+
+// SubnetCondition represents an observation of the Subnet state
+type SubnetCondition struct {
+	// Type represents the aspect the condition is about
+	Type SubnetConditionType `json:"type"`
+
+	// Status represents whether the condition holds, one of True, False or Unknown
+	Status metav1.ConditionStatus `json:"status"`
+
+	// Reason is a machine readable explanation of the status
+	Reason string `json:"reason,omitempty"`
+
+	// Message explains the status
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration represents the generation the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime represents when the status changed last
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // SubnetStatus defines the observed state of Subnet
 type SubnetStatus struct {
 	// Capacity represents the capacity of the subnet
-	Capacity int `json:"capacity,omitempty"`
+	Capacity resource.Quantity `json:"capacity,omitempty"`
 
 	// CapacityLeft represents the available capacity of the subnet
-	CapacityLeft int `json:"capacityLeft,omitempty"`
+	CapacityLeft resource.Quantity `json:"capacityLeft,omitempty"`
+
+	// DisplayCapacity represents the capacity in a readable form, e.g. 2^64 for a /64
+	DisplayCapacity string `json:"displayCapacity,omitempty"`
+
+	// DisplayCapacityLeft represents the available capacity in a readable form
+	DisplayCapacityLeft string `json:"displayCapacityLeft,omitempty"`
+
+	// FreeBlocks represents the largest CIDR blocks left free in the subnet, largest first
+	FreeBlocks []string `json:"freeBlocks,omitempty"`
+
+	// DisplayFreeBlocks represents a summary of the free blocks, e.g. "largest free block /25, 3 free /26s"
+	DisplayFreeBlocks string `json:"displayFreeBlocks,omitempty"`
+
+	// Gateway represents the effective gateway address of the subnet
+	Gateway string `json:"gateway,omitempty"`
+
+	// DNSServers represents the effective name servers of the subnet
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// SearchDomains represents the effective DNS search domains of the subnet
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// MTU represents the effective maximum transmission unit of the subnet
+	MTU int `json:"mtu,omitempty"`
+
+	// VLANID represents the effective VLAN of the subnet
+	VLANID int `json:"vlanID,omitempty"`
+
+	// State represents whether the subnet fits into the address plan
+	State SubnetState `json:"state,omitempty"`
+
+	// Reason is a machine readable explanation of a Pending or Invalid state
+	Reason string `json:"reason,omitempty"`
+
+	// Message explains why the subnet is pending or invalid
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration represents the generation the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represents the latest observations of the subnet
+	Conditions []SubnetCondition `json:"conditions,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if it isn't set.
+func (s *SubnetStatus) GetCondition(t SubnetConditionType) *SubnetCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition sets the condition, keeping the transition time if the status
+// stays the same. It reports whether the condition changed.
+func (s *SubnetStatus) SetCondition(condition SubnetCondition) bool {
+	existing := s.GetCondition(condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = metav1.Now()
+		s.Conditions = append(s.Conditions, condition)
+		return true
+	}
+	if existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+		return false
+	}
+	condition.LastTransitionTime = existing.LastTransitionTime
+	if existing.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	*existing = condition
+	return true
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CIDR",type=string,JSONPath=`.spec.cidr`
+// +kubebuilder:printcolumn:name="Capacity",type=string,JSONPath=`.status.displayCapacity`
+// +kubebuilder:printcolumn:name="Left",type=string,JSONPath=`.status.displayCapacityLeft`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Free",type=string,JSONPath=`.status.displayFreeBlocks`,priority=1
+// +kubebuilder:printcolumn:name="Gateway",type=string,JSONPath=`.status.gateway`,priority=1
+// +kubebuilder:printcolumn:name="VLAN",type=integer,JSONPath=`.status.vlanID`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Subnet is the Schema for the subnets API
 type Subnet struct {
@@ -0,0 +1,528 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// log is for logging in this package.
+var subnetlog = logf.Log.WithName("subnet-resource")
+
+// SetupWebhookWithManager registers the Subnet webhooks with the manager's webhook server.
+// The field indexes of the controllers have to be registered on the manager.
+func (r *Subnet) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register("/validate-core-gardener-cloud-v1-subnet", &webhook.Admission{Handler: &SubnetValidator{}})
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-core-gardener-cloud-v1-subnet,mutating=true,failurePolicy=fail,groups=core.gardener.cloud,resources=subnets,verbs=create;update,versions=v1,name=msubnet.kb.io
+
+var _ webhook.Defaulter = &Subnet{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It canonicalizes the CIDR, derives the Type from it and generates the ID if
+// it's missing.
+func (r *Subnet) Default() {
+	subnetlog.Info("default", "name", r.Name)
+
+	if ipNet, err := ipam.ParseCIDR(r.Spec.CIDR); err == nil {
+		r.Spec.CIDR = ipNet.String()
+		if r.Spec.Type == "" {
+			r.Spec.Type = ipam.Family(ipNet)
+		}
+	}
+	if r.Spec.ID == "" {
+		r.Spec.ID = string(uuid.NewUUID())
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-core-gardener-cloud-v1-subnet,mutating=false,failurePolicy=fail,groups=core.gardener.cloud,resources=subnets,verbs=create;update,versions=v1,name=vsubnet.kb.io
+
+// +kubebuilder:object:generate=false
+
+// SubnetValidator rejects Subnets that are malformed, reference unknown
+// objects or collide with the existing address plan.
+type SubnetValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// Handle implements admission.Handler.
+func (v *SubnetValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	subnet := &Subnet{}
+	if err := v.decoder.Decode(req, subnet); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var errs field.ErrorList
+	var old *Subnet
+	if len(req.OldObject.Raw) > 0 {
+		old = &Subnet{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// Finalizer and metadata changes must pass, or a Subnet whose
+		// references are gone could never be deleted.
+		if reflect.DeepEqual(old.Spec, subnet.Spec) || !subnet.DeletionTimestamp.IsZero() {
+			return admission.Allowed("")
+		}
+		errs = subnet.Spec.validateUpdate(field.NewPath("spec"), &old.Spec)
+	}
+
+	if len(errs) == 0 {
+		var err error
+		if errs, err = v.validate(ctx, subnet); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+	if len(errs) == 0 && old != nil {
+		var err error
+		if errs, err = v.validateRangesInUse(ctx, subnet, old); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+	if len(errs) > 0 {
+		subnetlog.Info("rejecting subnet", "name", subnet.Name, "errors", errs.ToAggregate().Error())
+		return admission.Denied(apierrors.NewInvalid(GroupVersion.WithKind("Subnet").GroupKind(), subnet.Name, errs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// validate checks the Subnet against its spec rules and the objects it
+// references. Errors talking to the API server are returned separately.
+func (v *SubnetValidator) validate(ctx context.Context, subnet *Subnet) (field.ErrorList, error) {
+	specPath := field.NewPath("spec")
+	errs := subnet.Spec.validate(specPath)
+	if len(errs) > 0 {
+		return errs, nil
+	}
+	ipNet, _ := ipam.ParseCIDR(subnet.Spec.CIDR)
+
+	if subnet.Spec.NetworkGlobalID != "" {
+		nGlobals := &netGlo.NetworkGlobalList{}
+		err := v.client.List(ctx, nGlobals, client.InNamespace(subnet.Namespace), client.MatchingFields{netGlo.IDField: subnet.Spec.NetworkGlobalID})
+		var nGlobal *netGlo.NetworkGlobal
+		if len(nGlobals.Items) > 0 {
+			nGlobal = &nGlobals.Items[0]
+		}
+		switch {
+		case err != nil:
+			return nil, err
+		case nGlobal == nil:
+			errs = append(errs, field.NotFound(specPath.Child("networkGlobalID"), subnet.Spec.NetworkGlobalID))
+		case subnet.Spec.SubnetParentID == "" && ipNet != nil && len(nGlobal.Spec.AddressSpaces) > 0 &&
+			!ipam.ContainedInAny(nGlobal.Spec.AddressSpaces, ipNet):
+			errs = append(errs, field.Invalid(specPath.Child("cidr"), subnet.Spec.CIDR,
+				fmt.Sprintf("must lie within an address space of networkGlobal %q %v", nGlobal.Name, nGlobal.Spec.AddressSpaces)))
+		}
+	}
+
+	if subnet.Spec.PartitionID != "" {
+		partition := &Partition{}
+		err := v.client.Get(ctx, client.ObjectKey{Name: subnet.Spec.PartitionID, Namespace: subnet.Namespace}, partition)
+		switch {
+		case apierrors.IsNotFound(err):
+			errs = append(errs, field.NotFound(specPath.Child("partitionID"), subnet.Spec.PartitionID))
+		case err != nil:
+			return nil, err
+		default:
+			errs = append(errs, validatePartitionPlacement(specPath, &subnet.Spec, partition, ipNet)...)
+		}
+	}
+
+	if parentName := subnet.Spec.SubnetParentID; parentName != "" {
+		parentPath := specPath.Child("subnetParentID")
+		parent := &Subnet{}
+		err := v.client.Get(ctx, client.ObjectKey{Name: parentName, Namespace: subnet.Namespace}, parent)
+		switch {
+		case parentName == subnet.Name:
+			errs = append(errs, field.Invalid(parentPath, parentName, "subnet can't be its own parent"))
+		case apierrors.IsNotFound(err):
+			errs = append(errs, field.NotFound(parentPath, parentName))
+		case err != nil:
+			return nil, err
+		case parent.Spec.NetworkGlobalID != subnet.Spec.NetworkGlobalID:
+			errs = append(errs, field.Invalid(parentPath, parentName,
+				fmt.Sprintf("parent belongs to networkGlobal %q", parent.Spec.NetworkGlobalID)))
+		default:
+			parentNet, err := ipam.ParseCIDR(parent.Spec.CIDR)
+			if ipNet == nil {
+				errs = append(errs, validateCarving(specPath, &subnet.Spec, parentNet)...)
+			} else if err != nil || !ipam.Contains(parentNet, ipNet) {
+				errs = append(errs, field.Invalid(specPath.Child("cidr"), subnet.Spec.CIDR,
+					fmt.Sprintf("must lie within parent subnet %q (%s)", parentName, parent.Spec.CIDR)))
+			} else if r, ok := reservedRangeOverlap(parent.Spec.ExcludedRanges(), ipNet); ok {
+				errs = append(errs, field.Invalid(specPath.Child("cidr"), subnet.Spec.CIDR,
+					fmt.Sprintf("overlaps the reserved range %s of parent subnet %q", r, parentName)))
+			}
+		}
+	}
+	if ipNet == nil {
+		// The CIDR is carved out of the parent later on, so there is nothing
+		// to collide with yet.
+		return errs, nil
+	}
+
+	subnets := &SubnetList{}
+	opts := []client.ListOption{client.InNamespace(subnet.Namespace)}
+	if subnet.Spec.NetworkGlobalID != "" {
+		opts = append(opts, client.MatchingFields{NetworkGlobalIDField: subnet.Spec.NetworkGlobalID})
+	}
+	if err := v.client.List(ctx, subnets, opts...); err != nil {
+		return nil, err
+	}
+	for _, sibling := range subnets.Items {
+		if sibling.Name == subnet.Name ||
+			sibling.Spec.SubnetParentID != subnet.Spec.SubnetParentID ||
+			sibling.Spec.NetworkGlobalID != subnet.Spec.NetworkGlobalID {
+			continue
+		}
+		siblingNet, err := ipam.ParseCIDR(sibling.Spec.CIDR)
+		if err != nil {
+			continue
+		}
+		if ipam.Overlaps(ipNet, siblingNet) {
+			errs = append(errs, field.Invalid(specPath.Child("cidr"), subnet.Spec.CIDR,
+				fmt.Sprintf("overlaps subnet %q (%s)", sibling.Name, sibling.Spec.CIDR)))
+		}
+	}
+	return errs, nil
+}
+
+// validateRangesInUse rejects reserved ranges and DHCP pools added to a Subnet
+// that overlap one of its child Subnets or an address bound to a claim. The
+// address management would only keep new children and claims out of them.
+func (v *SubnetValidator) validateRangesInUse(ctx context.Context, subnet, old *Subnet) (field.ErrorList, error) {
+	existing := make(map[AddressRange]bool)
+	for _, r := range old.Spec.ExcludedRanges() {
+		existing[r] = true
+	}
+	type addedRange struct {
+		path        *field.Path
+		r           AddressRange
+		first, last net.IP
+	}
+	var added []addedRange
+	specPath := field.NewPath("spec")
+	for _, ranges := range []struct {
+		path   *field.Path
+		ranges []AddressRange
+	}{
+		{specPath.Child("reservedRanges"), subnet.Spec.ReservedRanges},
+		{specPath.Child("dhcpPools"), subnet.Spec.DHCPPools},
+	} {
+		for i, r := range ranges.ranges {
+			if existing[r] {
+				continue
+			}
+			if first, last, err := ipam.ParseRange(r.Start, r.End); err == nil {
+				added = append(added, addedRange{ranges.path.Index(i), r, first, last})
+			}
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	children := &SubnetList{}
+	if err := v.client.List(ctx, children, client.InNamespace(subnet.Namespace), client.MatchingFields{SubnetParentIDField: subnet.Name}); err != nil {
+		return nil, err
+	}
+	claims := &IPAddressClaimList{}
+	if err := v.client.List(ctx, claims, client.InNamespace(subnet.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var errs field.ErrorList
+	for _, a := range added {
+		for _, child := range children.Items {
+			if childNet, err := ipam.ParseCIDR(child.Spec.CIDR); err == nil && ipam.OverlapsRange(childNet, a.first, a.last) {
+				errs = append(errs, field.Invalid(a.path, a.r.String(),
+					fmt.Sprintf("overlaps child subnet %q (%s)", child.Name, child.Spec.CIDR)))
+			}
+		}
+		dualStack := subnet.Labels[DualStackSubnetLabel]
+		for _, claim := range claims.Items {
+			if claim.Status.State != IPAddressClaimStateBound ||
+				(claim.Spec.SubnetID != subnet.Name && (dualStack == "" || claim.Spec.DualStackSubnetID != dualStack)) {
+				continue
+			}
+			for _, address := range []string{claim.Status.Address, claim.Status.IPv6Address} {
+				if ip := net.ParseIP(address); ip != nil && ipam.RangeContains(a.first, a.last, ip) {
+					errs = append(errs, field.Invalid(a.path, a.r.String(),
+						fmt.Sprintf("contains the address %s bound to claim %q", address, claim.Name)))
+				}
+			}
+		}
+	}
+	return errs, nil
+}
+
+// Validate checks the spec of the Subnet on its own, and that the fields
+// placing it in the address plan didn't change if old is set. The references
+// and overlaps are left to the webhook.
+func (r *Subnet) Validate(old *Subnet) error {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if old != nil {
+		errs = r.Spec.validateUpdate(specPath, &old.Spec)
+	}
+	errs = append(errs, r.Spec.validate(specPath)...)
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("Subnet").GroupKind(), r.Name, errs)
+	}
+	return nil
+}
+
+// validate checks the fields of the spec that don't depend on other objects.
+func (s *SubnetSpec) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if s.NetworkGlobalID == "" {
+		errs = append(errs, field.Required(path.Child("networkGlobalID"), ""))
+	}
+
+	switch s.Type {
+	case SubnetTypeIPv4, SubnetTypeIPv6:
+	case "":
+		// A carved subnet takes the type of its parent.
+		if s.CIDR != "" {
+			errs = append(errs, field.Required(path.Child("type"), ""))
+		}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("type"), s.Type, []string{SubnetTypeIPv4, SubnetTypeIPv6}))
+	}
+	errs = append(errs, validateNetworkSettings(path, s.DNSServers, s.SearchDomains, s.MTU, s.Type)...)
+
+	cidrPath := path.Child("cidr")
+	if s.CIDR == "" {
+		if s.SubnetParentID == "" || s.PrefixLength == 0 {
+			return append(errs, field.Required(cidrPath, "unless subnetParentID and prefixLength are set"))
+		}
+		errs = append(errs, validateReservedRanges(path.Child("reservedRanges"), s.ReservedRanges, s.Type, nil)...)
+		errs = append(errs, validateReservedRanges(path.Child("dhcpPools"), s.DHCPPools, s.Type, nil)...)
+		return append(errs, validateGateway(path.Child("gateway"), s.Gateway, s.Type, nil)...)
+	}
+	ipNet, err := ipam.ParseCIDR(s.CIDR)
+	if err != nil {
+		return append(errs, field.Invalid(cidrPath, s.CIDR, "must be a valid CIDR"))
+	}
+	errs = append(errs, validateReservedRanges(path.Child("reservedRanges"), s.ReservedRanges, ipam.Family(ipNet), ipNet)...)
+	errs = append(errs, validateReservedRanges(path.Child("dhcpPools"), s.DHCPPools, ipam.Family(ipNet), ipNet)...)
+	errs = append(errs, validateGateway(path.Child("gateway"), s.Gateway, ipam.Family(ipNet), ipNet)...)
+	errs = append(errs, validatePoolsClearOfGateway(path.Child("dhcpPools"), s.DHCPPools, s.Gateway, ipNet)...)
+	if ipNet.String() != s.CIDR {
+		errs = append(errs, field.Invalid(cidrPath, s.CIDR, fmt.Sprintf("must be written as %s", ipNet)))
+	}
+	if t := ipam.Family(ipNet); s.Type != "" && s.Type != t {
+		errs = append(errs, field.Invalid(path.Child("type"), s.Type, fmt.Sprintf("doesn't match the %s CIDR", t)))
+	}
+	if ones, _ := ipNet.Mask.Size(); s.PrefixLength != 0 && s.PrefixLength != ones {
+		errs = append(errs, field.Invalid(path.Child("prefixLength"), s.PrefixLength, fmt.Sprintf("doesn't match the /%d CIDR", ones)))
+	}
+	return errs
+}
+
+// validateUpdate rejects changes to the fields that place the Subnet in the
+// address plan. A field may only be set while it's empty, which is how the
+// controller records a carved CIDR and the defaulter an ID on older Subnets.
+func (s *SubnetSpec) validateUpdate(path *field.Path, old *SubnetSpec) field.ErrorList {
+	var errs field.ErrorList
+	oldCIDR := old.CIDR
+	if ipNet, err := ipam.ParseCIDR(oldCIDR); err == nil {
+		// The defaulter canonicalizes CIDRs stored before the webhook existed.
+		oldCIDR = ipNet.String()
+	}
+	for _, f := range []struct {
+		name       string
+		value, old string
+	}{
+		{"ID", s.ID, old.ID},
+		{"cidr", s.CIDR, oldCIDR},
+		{"networkGlobalID", s.NetworkGlobalID, old.NetworkGlobalID},
+		{"subnetParentID", s.SubnetParentID, old.SubnetParentID},
+		{"partitionID", s.PartitionID, old.PartitionID},
+	} {
+		if f.old != "" && f.value != f.old {
+			errs = append(errs, field.Invalid(path.Child(f.name), f.value, fmt.Sprintf("is immutable once set, was %q", f.old)))
+		}
+	}
+	return errs
+}
+
+// validateReservedRanges checks that the reserved ranges are well formed, of
+// the address family and, if the network is known, within it.
+func validateReservedRanges(path *field.Path, ranges []AddressRange, family string, ipNet *net.IPNet) field.ErrorList {
+	var errs field.ErrorList
+	for i, r := range ranges {
+		rangePath := path.Index(i)
+		first, last, err := ipam.ParseRange(r.Start, r.End)
+		if err != nil {
+			errs = append(errs, field.Invalid(rangePath, r.String(), err.Error()))
+			continue
+		}
+		switch {
+		case family != "" && ipam.AddressFamily(first) != family:
+			errs = append(errs, field.Invalid(rangePath, r.String(), fmt.Sprintf("must be an %s range", family)))
+		case ipNet != nil && (!ipNet.Contains(first) || !ipNet.Contains(last)):
+			errs = append(errs, field.Invalid(rangePath, r.String(), fmt.Sprintf("must lie within %s", ipNet)))
+		}
+	}
+	return errs
+}
+
+// validateGateway checks that the gateway is a usable address of the network
+// or, while the network isn't known yet, of the address family.
+func validateGateway(path *field.Path, gateway, family string, ipNet *net.IPNet) field.ErrorList {
+	var errs field.ErrorList
+	if gateway == "" {
+		return errs
+	}
+	ip := net.ParseIP(gateway)
+	switch {
+	case ip == nil:
+		errs = append(errs, field.Invalid(path, gateway, "must be a valid IP address"))
+	case family != "" && ipam.AddressFamily(ip) != family:
+		errs = append(errs, field.Invalid(path, gateway, fmt.Sprintf("must be an %s address", family)))
+	case ipNet != nil && !ipam.Usable(ipNet).Has(ip):
+		errs = append(errs, field.Invalid(path, gateway, fmt.Sprintf("must be a usable address of %s", ipNet)))
+	}
+	return errs
+}
+
+// validateNetworkSettings checks the name servers, the search domains and the
+// MTU, which has to be at least 1280 for IPv6.
+func validateNetworkSettings(path *field.Path, dnsServers, searchDomains []string, mtu int, family string) field.ErrorList {
+	var errs field.ErrorList
+	for i, server := range dnsServers {
+		if net.ParseIP(server) == nil {
+			errs = append(errs, field.Invalid(path.Child("dnsServers").Index(i), server, "must be a valid IP address"))
+		}
+	}
+	for i, domain := range searchDomains {
+		for _, msg := range validation.IsDNS1123Subdomain(domain) {
+			errs = append(errs, field.Invalid(path.Child("searchDomains").Index(i), domain, msg))
+		}
+	}
+	if family == SubnetTypeIPv6 && mtu != 0 && mtu < 1280 {
+		errs = append(errs, field.Invalid(path.Child("mtu"), mtu, "must be at least 1280 for IPv6"))
+	}
+	return errs
+}
+
+// validatePoolsClearOfGateway checks that the DHCP servers don't lease the
+// gateway, the explicit one or the first usable host.
+func validatePoolsClearOfGateway(path *field.Path, pools []AddressRange, gateway string, ipNet *net.IPNet) field.ErrorList {
+	var errs field.ErrorList
+	ip := ipam.DefaultGateway(ipNet)
+	if gateway != "" {
+		ip = net.ParseIP(gateway)
+	}
+	if ip == nil {
+		return errs
+	}
+	for i, pool := range pools {
+		if first, last, err := ipam.ParseRange(pool.Start, pool.End); err == nil && ipam.RangeContains(first, last, ip) {
+			errs = append(errs, field.Invalid(path.Index(i), pool.String(), fmt.Sprintf("must not contain the gateway %s", ip)))
+		}
+	}
+	return errs
+}
+
+// reservedRangeOverlap returns the first of the reserved ranges that overlaps
+// the network.
+func reservedRangeOverlap(ranges []AddressRange, ipNet *net.IPNet) (AddressRange, bool) {
+	for _, r := range ranges {
+		if first, last, err := ipam.ParseRange(r.Start, r.End); err == nil && ipam.OverlapsRange(ipNet, first, last) {
+			return r, true
+		}
+	}
+	return AddressRange{}, false
+}
+
+// validatePartitionPlacement checks that the partition offers the address
+// family of the subnet and that the subnet keeps clear of its reserved ranges.
+func validatePartitionPlacement(path *field.Path, s *SubnetSpec, partition *Partition, ipNet *net.IPNet) field.ErrorList {
+	var errs field.ErrorList
+	family := s.Type
+	if ipNet != nil {
+		family = ipam.Family(ipNet)
+	}
+	if family != "" && !partition.Spec.AllowsFamily(family) {
+		errs = append(errs, field.Invalid(path.Child("partitionID"), s.PartitionID,
+			fmt.Sprintf("partition doesn't offer %s", family)))
+	}
+	if ipNet == nil {
+		return errs
+	}
+	for _, reserved := range partition.Spec.ReservedRanges {
+		if reservedNet, err := ipam.ParseCIDR(reserved); err == nil && ipam.Overlaps(ipNet, reservedNet) {
+			errs = append(errs, field.Invalid(path.Child("cidr"), s.CIDR,
+				fmt.Sprintf("overlaps the reserved range %s of partition %q", reserved, partition.Name)))
+		}
+	}
+	return errs
+}
+
+// validateCarving checks that a block of the requested prefix length fits into
+// the parent network. A parent that is waiting to be carved itself can't be
+// checked yet; the controller keeps the Subnet pending until it is.
+func validateCarving(path *field.Path, s *SubnetSpec, parentNet *net.IPNet) field.ErrorList {
+	var errs field.ErrorList
+	if parentNet == nil {
+		return nil
+	}
+	if t := ipam.Family(parentNet); s.Type != "" && s.Type != t {
+		errs = append(errs, field.Invalid(path.Child("type"), s.Type, fmt.Sprintf("doesn't match the %s parent", t)))
+	}
+	if ones, bits := parentNet.Mask.Size(); s.PrefixLength < ones || s.PrefixLength > bits {
+		errs = append(errs, field.Invalid(path.Child("prefixLength"), s.PrefixLength,
+			fmt.Sprintf("must be between %d and %d to fit into the parent %s", ones, bits, parentNet)))
+	}
+	return errs
+}
+
+// InjectClient implements inject.Client.
+func (v *SubnetValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *SubnetValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
@@ -0,0 +1,253 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// indexedClient answers the field selectors of the webhooks the way the field
+// indexes of the manager do, which the fake client ignores. The indexed
+// fields are named by their JSON path.
+type indexedClient struct {
+	client.Client
+}
+
+func (c indexedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil {
+		return nil
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var matches []runtime.Object
+	for _, item := range items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return err
+		}
+		matched := true
+		for _, r := range listOpts.FieldSelector.Requirements() {
+			value, _, _ := unstructured.NestedString(content, strings.Split(r.Field, ".")...)
+			matched = matched && value == r.Value
+		}
+		if matched {
+			matches = append(matches, item)
+		}
+	}
+	return meta.SetList(list, matches)
+}
+
+var _ = Describe("Subnet webhook", func() {
+	ctx := context.Background()
+	const namespace = "default"
+
+	var validator *SubnetValidator
+
+	subnet := func(name string, spec SubnetSpec) *Subnet {
+		if ipNet, err := ipam.ParseCIDR(spec.CIDR); err == nil && spec.Type == "" {
+			spec.Type = ipam.Family(ipNet)
+		}
+		return &Subnet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "Subnet"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spec,
+		}
+	}
+	parent := func() *Subnet {
+		return subnet("parent", SubnetSpec{
+			NetworkGlobalID: "net",
+			CIDR:            "10.0.0.0/20",
+			ReservedRanges:  []AddressRange{{Start: "10.0.15.0", End: "10.0.15.255"}},
+		})
+	}
+
+	BeforeEach(func() {
+		Expect(AddToScheme(scheme.Scheme)).To(Succeed())
+		Expect(netGlo.AddToScheme(scheme.Scheme)).To(Succeed())
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		validator = &SubnetValidator{}
+		Expect(validator.InjectDecoder(decoder)).To(Succeed())
+		Expect(validator.InjectClient(indexedClient{fake.NewFakeClientWithScheme(scheme.Scheme,
+			&netGlo.NetworkGlobal{
+				ObjectMeta: metav1.ObjectMeta{Name: "net", Namespace: namespace},
+				Spec:       netGlo.NetworkGlobalSpec{ID: "net", AddressSpaces: []string{"10.0.0.0/16", "fd00::/48"}},
+			},
+			&netGlo.NetworkGlobal{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: namespace},
+				Spec:       netGlo.NetworkGlobalSpec{ID: "other", AddressSpaces: []string{"10.1.0.0/16"}},
+			},
+			&Partition{
+				ObjectMeta: metav1.ObjectMeta{Name: "rack1", Namespace: namespace},
+				Spec: PartitionSpec{
+					AddressFamilies: []AddressFamily{SubnetTypeIPv4},
+					ReservedRanges:  []string{"10.0.200.0/24"},
+				},
+			},
+			parent(),
+			subnet("child", SubnetSpec{NetworkGlobalID: "net", SubnetParentID: "parent", CIDR: "10.0.1.0/24"}),
+			subnet("sibling", SubnetSpec{NetworkGlobalID: "net", CIDR: "10.0.16.0/24"}),
+			subnet("elsewhere", SubnetSpec{NetworkGlobalID: "other", CIDR: "10.1.0.0/24"}),
+			&IPAddressClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: namespace},
+				Spec:       IPAddressClaimSpec{SubnetID: "parent"},
+				Status:     IPAddressClaimStatus{State: IPAddressClaimStateBound, Address: "10.0.2.5"},
+			},
+		)})).To(Succeed())
+	})
+
+	DescribeTable("validates new subnets",
+		func(spec SubnetSpec, fields ...string) {
+			if spec.NetworkGlobalID == "" {
+				spec.NetworkGlobalID = "net"
+			}
+			errs, err := validator.validate(ctx, subnet("new", spec))
+			Expect(err).NotTo(HaveOccurred())
+			var invalid []string
+			for _, e := range errs {
+				invalid = append(invalid, e.Field)
+			}
+			Expect(invalid).To(ConsistOf(fields))
+		},
+		Entry("a top-level subnet", SubnetSpec{CIDR: "10.0.32.0/24"}),
+		Entry("a child subnet", SubnetSpec{SubnetParentID: "parent", CIDR: "10.0.2.0/24"}),
+		Entry("a subnet to be carved", SubnetSpec{SubnetParentID: "parent", PrefixLength: 26}),
+		Entry("a subnet of another networkGlobal", SubnetSpec{NetworkGlobalID: "other", CIDR: "10.1.5.0/24"}),
+		Entry("a malformed CIDR", SubnetSpec{Type: SubnetTypeIPv4, CIDR: "10.0.32.0/33"}, "spec.cidr"),
+		Entry("a CIDR that isn't canonical", SubnetSpec{CIDR: "10.0.32.1/24"}, "spec.cidr"),
+		Entry("a type that doesn't match the CIDR", SubnetSpec{Type: SubnetTypeIPv6, CIDR: "10.0.32.0/24"}, "spec.type"),
+		Entry("a carved subnet of the other family",
+			SubnetSpec{Type: SubnetTypeIPv6, SubnetParentID: "parent", PrefixLength: 64}, "spec.type", "spec.prefixLength"),
+		Entry("a carved subnet larger than its parent",
+			SubnetSpec{SubnetParentID: "parent", PrefixLength: 16}, "spec.prefixLength"),
+		Entry("a CIDR outside the address spaces", SubnetSpec{CIDR: "10.2.0.0/24"}, "spec.cidr"),
+		Entry("a CIDR overlapping a sibling", SubnetSpec{CIDR: "10.0.16.128/25"}, "spec.cidr"),
+		Entry("a CIDR overlapping a sibling under the same parent",
+			SubnetSpec{SubnetParentID: "parent", CIDR: "10.0.1.128/25"}, "spec.cidr"),
+		Entry("a CIDR outside the parent", SubnetSpec{SubnetParentID: "parent", CIDR: "10.0.32.0/24"}, "spec.cidr"),
+		Entry("a CIDR overlapping a reserved range of the parent",
+			SubnetSpec{SubnetParentID: "parent", CIDR: "10.0.15.0/24"}, "spec.cidr"),
+		Entry("an unknown networkGlobal", SubnetSpec{NetworkGlobalID: "missing", CIDR: "10.0.32.0/24"}, "spec.networkGlobalID"),
+		Entry("an unknown parent", SubnetSpec{SubnetParentID: "missing", CIDR: "10.0.2.0/24"}, "spec.subnetParentID"),
+		Entry("a parent of another networkGlobal",
+			SubnetSpec{NetworkGlobalID: "other", SubnetParentID: "parent", CIDR: "10.0.2.0/24"}, "spec.subnetParentID"),
+		Entry("the subnet as its own parent", SubnetSpec{SubnetParentID: "new", CIDR: "10.0.33.0/24"}, "spec.subnetParentID"),
+		Entry("an unknown partition", SubnetSpec{PartitionID: "missing", CIDR: "10.0.32.0/24"}, "spec.partitionID"),
+		Entry("a family the partition doesn't offer", SubnetSpec{PartitionID: "rack1", CIDR: "fd00::/64"}, "spec.partitionID"),
+		Entry("a CIDR overlapping a reserved range of the partition",
+			SubnetSpec{PartitionID: "rack1", CIDR: "10.0.200.0/24"}, "spec.cidr"),
+	)
+
+	DescribeTable("validates updates",
+		func(mutate func(old, updated *Subnet), field string) {
+			old := parent()
+			old.Spec.ID = "0b5d1a44"
+			updated := old.DeepCopy()
+			mutate(old, updated)
+
+			request := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    rawObject(updated),
+				OldObject: rawObject(old),
+			}}
+			response := validator.Handle(ctx, request)
+			if field == "" {
+				Expect(response.Allowed).To(BeTrue(), "denied: %s", response.Result.Reason)
+				return
+			}
+			Expect(response.Allowed).To(BeFalse())
+			Expect(string(response.Result.Reason)).To(ContainSubstring(field + ":"))
+		},
+		Entry("a new label", func(old, updated *Subnet) {
+			updated.Labels = map[string]string{"rack": "1"}
+		}, ""),
+		Entry("a new DNS server", func(old, updated *Subnet) {
+			updated.Spec.DNSServers = []string{"10.0.0.53"}
+		}, ""),
+		Entry("a changed ID", func(old, updated *Subnet) {
+			updated.Spec.ID = "ffd1c2a3"
+		}, "spec.ID"),
+		Entry("a changed CIDR", func(old, updated *Subnet) {
+			updated.Spec.CIDR = "10.0.0.0/19"
+		}, "spec.cidr"),
+		Entry("a changed networkGlobalID", func(old, updated *Subnet) {
+			updated.Spec.NetworkGlobalID = "other"
+		}, "spec.networkGlobalID"),
+		Entry("a changed subnetParentID", func(old, updated *Subnet) {
+			old.Spec.SubnetParentID = "sibling"
+		}, "spec.subnetParentID"),
+		Entry("a changed partitionID", func(old, updated *Subnet) {
+			old.Spec.PartitionID = "rack0"
+			updated.Spec.PartitionID = "rack1"
+		}, "spec.partitionID"),
+		Entry("a partitionID set for the first time", func(old, updated *Subnet) {
+			updated.Spec.PartitionID = "rack1"
+		}, ""),
+		Entry("a carved CIDR recorded by the controller", func(old, updated *Subnet) {
+			old.Spec.CIDR, old.Spec.PrefixLength = "", 20
+			updated.Spec.PrefixLength = 20
+		}, ""),
+		Entry("a CIDR stored before it was canonicalized", func(old, updated *Subnet) {
+			old.Spec.CIDR = "10.0.0.1/20"
+		}, ""),
+		Entry("a reserved range over a child subnet", func(old, updated *Subnet) {
+			updated.Spec.ReservedRanges = append(updated.Spec.ReservedRanges, AddressRange{Start: "10.0.1.10", End: "10.0.1.20"})
+		}, "spec.reservedRanges[1]"),
+		Entry("a DHCP pool over a bound address", func(old, updated *Subnet) {
+			updated.Spec.DHCPPools = []AddressRange{{Start: "10.0.2.2", End: "10.0.2.9"}}
+		}, "spec.dhcpPools[0]"),
+		Entry("a spec change while the subnet is being deleted", func(old, updated *Subnet) {
+			now := metav1.Now()
+			old.DeletionTimestamp, updated.DeletionTimestamp = &now, &now
+			updated.Spec.NetworkGlobalID = "other"
+		}, ""),
+	)
+})
+
+// rawObject returns the object the way the API server hands it to a webhook.
+func rawObject(obj runtime.Object) runtime.RawExtension {
+	raw, err := json.Marshal(obj)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return runtime.RawExtension{Raw: raw}
+}
@@ -0,0 +1,50 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+)
+
+func TestAPI(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"API Suite",
+		[]Reporter{printer.NewlineReporter{}})
+}
+
+// invalidFields returns the fields an Invalid error of a webhook complains
+// about, or nil if err is nil.
+func invalidFields(err error) []string {
+	if err == nil {
+		return nil
+	}
+	statusErr, ok := err.(*apierrors.StatusError)
+	ExpectWithOffset(1, ok).To(BeTrue(), "not a status error: %v", err)
+	ExpectWithOffset(1, apierrors.IsInvalid(err)).To(BeTrue(), "not an Invalid error: %v", err)
+	var fields []string
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	return fields
+}
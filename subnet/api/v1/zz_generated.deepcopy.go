@@ -21,11 +21,171 @@ limitations under the License.
 package v1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Subnet) DeepCopyInto(out *Subnet) {
+func (in *AddressRange) DeepCopyInto(out *AddressRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressRange.
+func (in *AddressRange) DeepCopy() *AddressRange {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DualStackFamilySpec) DeepCopyInto(out *DualStackFamilySpec) {
+	*out = *in
+	if in.ReservedRanges != nil {
+		in, out := &in.ReservedRanges, &out.ReservedRanges
+		*out = make([]AddressRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.DHCPPools != nil {
+		in, out := &in.DHCPPools, &out.DHCPPools
+		*out = make([]AddressRange, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DualStackFamilySpec.
+func (in *DualStackFamilySpec) DeepCopy() *DualStackFamilySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DualStackFamilySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DualStackFamilyStatus) DeepCopyInto(out *DualStackFamilyStatus) {
+	*out = *in
+	out.Capacity = in.Capacity.DeepCopy()
+	out.CapacityLeft = in.CapacityLeft.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DualStackFamilyStatus.
+func (in *DualStackFamilyStatus) DeepCopy() *DualStackFamilyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DualStackFamilyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DualStackSubnet) DeepCopyInto(out *DualStackSubnet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DualStackSubnet.
+func (in *DualStackSubnet) DeepCopy() *DualStackSubnet {
+	if in == nil {
+		return nil
+	}
+	out := new(DualStackSubnet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DualStackSubnet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DualStackSubnetList) DeepCopyInto(out *DualStackSubnetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DualStackSubnet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DualStackSubnetList.
+func (in *DualStackSubnetList) DeepCopy() *DualStackSubnetList {
+	if in == nil {
+		return nil
+	}
+	out := new(DualStackSubnetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DualStackSubnetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DualStackSubnetSpec) DeepCopyInto(out *DualStackSubnetSpec) {
+	*out = *in
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.IPv4.DeepCopyInto(&out.IPv4)
+	in.IPv6.DeepCopyInto(&out.IPv6)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DualStackSubnetSpec.
+func (in *DualStackSubnetSpec) DeepCopy() *DualStackSubnetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DualStackSubnetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DualStackSubnetStatus) DeepCopyInto(out *DualStackSubnetStatus) {
+	*out = *in
+	in.IPv4.DeepCopyInto(&out.IPv4)
+	in.IPv6.DeepCopyInto(&out.IPv6)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DualStackSubnetStatus.
+func (in *DualStackSubnetStatus) DeepCopy() *DualStackSubnetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DualStackSubnetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressClaim) DeepCopyInto(out *IPAddressClaim) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -33,6 +193,213 @@ func (in *Subnet) DeepCopyInto(out *Subnet) {
 	out.Status = in.Status
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressClaim.
+func (in *IPAddressClaim) DeepCopy() *IPAddressClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IPAddressClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressClaimList) DeepCopyInto(out *IPAddressClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IPAddressClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressClaimList.
+func (in *IPAddressClaimList) DeepCopy() *IPAddressClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IPAddressClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressClaimSpec) DeepCopyInto(out *IPAddressClaimSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressClaimSpec.
+func (in *IPAddressClaimSpec) DeepCopy() *IPAddressClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressClaimStatus) DeepCopyInto(out *IPAddressClaimStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressClaimStatus.
+func (in *IPAddressClaimStatus) DeepCopy() *IPAddressClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Partition) DeepCopyInto(out *Partition) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Partition.
+func (in *Partition) DeepCopy() *Partition {
+	if in == nil {
+		return nil
+	}
+	out := new(Partition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Partition) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionAddressUsage) DeepCopyInto(out *PartitionAddressUsage) {
+	*out = *in
+	out.Capacity = in.Capacity.DeepCopy()
+	out.Used = in.Used.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionAddressUsage.
+func (in *PartitionAddressUsage) DeepCopy() *PartitionAddressUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionAddressUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionList) DeepCopyInto(out *PartitionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Partition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionList.
+func (in *PartitionList) DeepCopy() *PartitionList {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PartitionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionSpec) DeepCopyInto(out *PartitionSpec) {
+	*out = *in
+	if in.AddressFamilies != nil {
+		in, out := &in.AddressFamilies, &out.AddressFamilies
+		*out = make([]AddressFamily, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReservedRanges != nil {
+		in, out := &in.ReservedRanges, &out.ReservedRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionSpec.
+func (in *PartitionSpec) DeepCopy() *PartitionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionStatus) DeepCopyInto(out *PartitionStatus) {
+	*out = *in
+	in.IPv4.DeepCopyInto(&out.IPv4)
+	in.IPv6.DeepCopyInto(&out.IPv6)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionStatus.
+func (in *PartitionStatus) DeepCopy() *PartitionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Subnet) DeepCopyInto(out *Subnet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subnet.
 func (in *Subnet) DeepCopy() *Subnet {
 	if in == nil {
@@ -51,6 +418,22 @@ func (in *Subnet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubnetCondition) DeepCopyInto(out *SubnetCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetCondition.
+func (in *SubnetCondition) DeepCopy() *SubnetCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(SubnetCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubnetList) DeepCopyInto(out *SubnetList) {
 	*out = *in
@@ -86,6 +469,26 @@ func (in *SubnetList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubnetSpec) DeepCopyInto(out *SubnetSpec) {
 	*out = *in
+	if in.ReservedRanges != nil {
+		in, out := &in.ReservedRanges, &out.ReservedRanges
+		*out = make([]AddressRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.DHCPPools != nil {
+		in, out := &in.DHCPPools, &out.DHCPPools
+		*out = make([]AddressRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetSpec.
@@ -101,6 +504,30 @@ func (in *SubnetSpec) DeepCopy() *SubnetSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubnetStatus) DeepCopyInto(out *SubnetStatus) {
 	*out = *in
+	out.Capacity = in.Capacity.DeepCopy()
+	out.CapacityLeft = in.CapacityLeft.DeepCopy()
+	if in.FreeBlocks != nil {
+		in, out := &in.FreeBlocks, &out.FreeBlocks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]SubnetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetStatus.
@@ -0,0 +1,126 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	corev1 "gardener/subnet/api/v1"
+)
+
+// The functions below copy objects of the legacy groups into this group.
+// Finalizers and owner references are left out, as they name the legacy
+// controllers and objects.
+
+// NetworkGlobalFromLegacy returns the NetworkGlobal of this group the legacy one is migrated to.
+func NetworkGlobalFromLegacy(in *netGlo.NetworkGlobal) *NetworkGlobal {
+	return &NetworkGlobal{
+		ObjectMeta: objectMetaFromLegacy(&in.ObjectMeta),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+// SubnetFromLegacy returns the Subnet of this group the legacy one is migrated to.
+func SubnetFromLegacy(in *corev1.Subnet) *Subnet {
+	return &Subnet{
+		ObjectMeta: objectMetaFromLegacy(&in.ObjectMeta),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+// PartitionFromLegacy returns the Partition of this group the legacy one is migrated to.
+func PartitionFromLegacy(in *corev1.Partition) *Partition {
+	return &Partition{
+		ObjectMeta: objectMetaFromLegacy(&in.ObjectMeta),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+// DualStackSubnetFromLegacy returns the DualStackSubnet of this group the legacy one is migrated to.
+func DualStackSubnetFromLegacy(in *corev1.DualStackSubnet) *DualStackSubnet {
+	return &DualStackSubnet{
+		ObjectMeta: objectMetaFromLegacy(&in.ObjectMeta),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+// IPAddressClaimFromLegacy returns the IPAddressClaim of this group the legacy one is migrated to.
+func IPAddressClaimFromLegacy(in *corev1.IPAddressClaim) *IPAddressClaim {
+	return &IPAddressClaim{
+		ObjectMeta: objectMetaFromLegacy(&in.ObjectMeta),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+func objectMetaFromLegacy(in *metav1.ObjectMeta) metav1.ObjectMeta {
+	meta := in.DeepCopy()
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
+
+// The functions below copy objects of this group into the legacy types, so
+// that they are checked by the same rules.
+
+func legacyNetworkGlobal(in *NetworkGlobal) *netGlo.NetworkGlobal {
+	return &netGlo.NetworkGlobal{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+func legacySubnet(in *Subnet) *corev1.Subnet {
+	return &corev1.Subnet{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+func legacyPartition(in *Partition) *corev1.Partition {
+	return &corev1.Partition{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+func legacyDualStackSubnet(in *DualStackSubnet) *corev1.DualStackSubnet {
+	return &corev1.DualStackSubnet{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
+
+func legacyIPAddressClaim(in *IPAddressClaim) *corev1.IPAddressClaim {
+	return &corev1.IPAddressClaim{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec:       *in.Spec.DeepCopy(),
+		Status:     *in.Status.DeepCopy(),
+	}
+}
@@ -0,0 +1,149 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	corev1 "gardener/subnet/api/v1"
+)
+
+// legacyMeta returns metadata carrying everything that mustn't survive the
+// migration along with what must.
+func legacyMeta() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:            "legacy",
+		Namespace:       "default",
+		UID:             types.UID("legacy-uid"),
+		ResourceVersion: "42",
+		Generation:      3,
+		Labels:          map[string]string{"app": "network"},
+		Annotations:     map[string]string{"note": "migrated"},
+		Finalizers:      []string{"core.gardener.cloud/subnet"},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: corev1.GroupVersion.String(),
+			Kind:       "DualStackSubnet",
+			Name:       "owner",
+			UID:        types.UID("owner-uid"),
+		}},
+	}
+}
+
+var _ = Describe("Conversion from the legacy groups", func() {
+	DescribeTable("keeps only the metadata that doesn't name legacy objects",
+		func(convert func(metav1.ObjectMeta) metav1.Object) {
+			migrated := convert(legacyMeta())
+
+			Expect(migrated.GetName()).To(Equal("legacy"))
+			Expect(migrated.GetNamespace()).To(Equal("default"))
+			Expect(migrated.GetLabels()).To(Equal(map[string]string{"app": "network"}))
+			Expect(migrated.GetAnnotations()).To(Equal(map[string]string{"note": "migrated"}))
+			Expect(migrated.GetUID()).To(BeEmpty())
+			Expect(migrated.GetResourceVersion()).To(BeEmpty())
+			Expect(migrated.GetGeneration()).To(BeZero())
+			Expect(migrated.GetFinalizers()).To(BeEmpty())
+			Expect(migrated.GetOwnerReferences()).To(BeEmpty())
+		},
+		Entry("NetworkGlobal", func(meta metav1.ObjectMeta) metav1.Object {
+			return NetworkGlobalFromLegacy(&netGlo.NetworkGlobal{ObjectMeta: meta})
+		}),
+		Entry("Subnet", func(meta metav1.ObjectMeta) metav1.Object {
+			return SubnetFromLegacy(&corev1.Subnet{ObjectMeta: meta})
+		}),
+		Entry("Partition", func(meta metav1.ObjectMeta) metav1.Object {
+			return PartitionFromLegacy(&corev1.Partition{ObjectMeta: meta})
+		}),
+		Entry("DualStackSubnet", func(meta metav1.ObjectMeta) metav1.Object {
+			return DualStackSubnetFromLegacy(&corev1.DualStackSubnet{ObjectMeta: meta})
+		}),
+		Entry("IPAddressClaim", func(meta metav1.ObjectMeta) metav1.Object {
+			return IPAddressClaimFromLegacy(&corev1.IPAddressClaim{ObjectMeta: meta})
+		}),
+	)
+
+	It("copies the spec and the status of a NetworkGlobal", func() {
+		legacy := &netGlo.NetworkGlobal{
+			ObjectMeta: legacyMeta(),
+			Spec: netGlo.NetworkGlobalSpec{
+				ID:             "net",
+				AddressSpaces:  []string{"10.0.0.0/16", "fd00::/48"},
+				DeletionPolicy: netGlo.DeletionPolicyCascade,
+			},
+			Status: netGlo.NetworkGlobalStatus{
+				ObservedGeneration: 3,
+				Conditions: []netGlo.NetworkGlobalCondition{{
+					Type:   netGlo.NetworkGlobalValid,
+					Status: metav1.ConditionTrue,
+				}},
+			},
+		}
+
+		migrated := NetworkGlobalFromLegacy(legacy)
+		Expect(migrated.Spec).To(Equal(legacy.Spec))
+		Expect(migrated.Status).To(Equal(legacy.Status))
+	})
+
+	It("copies the spec and the status of a Subnet", func() {
+		legacy := &corev1.Subnet{
+			ObjectMeta: legacyMeta(),
+			Spec: corev1.SubnetSpec{
+				Type:            corev1.SubnetTypeIPv4,
+				CIDR:            "10.0.1.0/24",
+				NetworkGlobalID: "net",
+				ReservedRanges:  []corev1.AddressRange{{Start: "10.0.1.10", End: "10.0.1.20"}},
+				DNSServers:      []string{"10.0.0.53"},
+			},
+			Status: corev1.SubnetStatus{
+				State:      corev1.SubnetStateValid,
+				FreeBlocks: []string{"10.0.1.128/25"},
+				Gateway:    "10.0.1.1",
+			},
+		}
+
+		migrated := SubnetFromLegacy(legacy)
+		Expect(migrated.Spec).To(Equal(legacy.Spec))
+		Expect(migrated.Status).To(Equal(legacy.Status))
+	})
+
+	It("doesn't share anything with the legacy object", func() {
+		legacy := &corev1.Subnet{
+			ObjectMeta: legacyMeta(),
+			Spec: corev1.SubnetSpec{
+				DNSServers: []string{"10.0.0.53"},
+			},
+			Status: corev1.SubnetStatus{
+				FreeBlocks: []string{"10.0.1.128/25"},
+			},
+		}
+
+		migrated := SubnetFromLegacy(legacy)
+		legacy.Labels["app"] = "changed"
+		legacy.Annotations["note"] = "changed"
+		legacy.Spec.DNSServers[0] = "changed"
+		legacy.Status.FreeBlocks[0] = "changed"
+
+		Expect(migrated.Labels).To(HaveKeyWithValue("app", "network"))
+		Expect(migrated.Annotations).To(HaveKeyWithValue("note", "migrated"))
+		Expect(migrated.Spec.DNSServers).To(Equal([]string{"10.0.0.53"}))
+		Expect(migrated.Status.FreeBlocks).To(Equal([]string{"10.0.1.128/25"}))
+	})
+})
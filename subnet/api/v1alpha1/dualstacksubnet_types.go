@@ -0,0 +1,50 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="IPv4",type=string,JSONPath=`.status.ipv4.cidr`
+// +kubebuilder:printcolumn:name="IPv6",type=string,JSONPath=`.status.ipv6.cidr`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DualStackSubnet is the Schema for the dualstacksubnets API. It shares its spec and status with
+// the core.gardener.cloud/v1 DualStackSubnet it replaces.
+type DualStackSubnet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   corev1.DualStackSubnetSpec   `json:"spec,omitempty"`
+	Status corev1.DualStackSubnetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DualStackSubnetList contains a list of DualStackSubnet
+type DualStackSubnetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DualStackSubnet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DualStackSubnet{}, &DualStackSubnetList{})
+}
@@ -0,0 +1,47 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package v1alpha1 contains API Schema definitions for the network v1alpha1 API group.
+// It hosts all network resources in one group. The kinds share their spec and
+// status with the legacy core.gardener.cloud/v1 and core.core.gardener.cloud/v1
+// groups, which are served alongside until the objects have been migrated.
+// The manager keeps the finalizers of its NetworkGlobals and Subnets and checks
+// its objects the way it checks the legacy ones, short of their references.
+// The status is only computed for the legacy objects so far.
+// +kubebuilder:object:generate=true
+// +groupName=network.onmetal.de
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "network.onmetal.de", Version: "v1alpha1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+// Finalizers of the objects in this group
+const (
+	NetworkGlobalFinalizer = "network.onmetal.de/networkglobal"
+	SubnetFinalizer        = "network.onmetal.de/subnet"
+)
@@ -0,0 +1,53 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ID",type=string,JSONPath=`.spec.id`
+// +kubebuilder:printcolumn:name="IPv4 Capacity",type=string,JSONPath=`.status.ipv4.displayCapacity`
+// +kubebuilder:printcolumn:name="IPv4 Used",type=string,JSONPath=`.status.ipv4.displayUsed`
+// +kubebuilder:printcolumn:name="IPv6 Capacity",type=string,JSONPath=`.status.ipv6.displayCapacity`,priority=1
+// +kubebuilder:printcolumn:name="IPv6 Used",type=string,JSONPath=`.status.ipv6.displayUsed`,priority=1
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NetworkGlobal is the Schema for the networkglobals API. It shares its spec and status with
+// the core.core.gardener.cloud/v1 NetworkGlobal it replaces.
+type NetworkGlobal struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   netGlo.NetworkGlobalSpec   `json:"spec,omitempty"`
+	Status netGlo.NetworkGlobalStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NetworkGlobalList contains a list of NetworkGlobal
+type NetworkGlobalList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NetworkGlobal `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NetworkGlobal{}, &NetworkGlobalList{})
+}
@@ -0,0 +1,53 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Region",type=string,JSONPath=`.spec.region`
+// +kubebuilder:printcolumn:name="Subnets",type=integer,JSONPath=`.status.subnets`
+// +kubebuilder:printcolumn:name="IPv4 Capacity",type=string,JSONPath=`.status.ipv4.displayCapacity`
+// +kubebuilder:printcolumn:name="IPv4 Used",type=string,JSONPath=`.status.ipv4.displayUsed`
+// +kubebuilder:printcolumn:name="IPv6 Capacity",type=string,JSONPath=`.status.ipv6.displayCapacity`,priority=1
+// +kubebuilder:printcolumn:name="IPv6 Used",type=string,JSONPath=`.status.ipv6.displayUsed`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Partition is the Schema for the partitions API. It shares its spec and status with
+// the core.gardener.cloud/v1 Partition it replaces.
+type Partition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   corev1.PartitionSpec   `json:"spec,omitempty"`
+	Status corev1.PartitionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PartitionList contains a list of Partition
+type PartitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Partition `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Partition{}, &PartitionList{})
+}
@@ -0,0 +1,155 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// The webhooks of this group share the checks of the legacy kinds that don't
+// depend on other objects. References and overlaps are only checked for the
+// legacy objects, which are still the ones the controllers compute.
+
+// SetupWebhooksWithManager registers the webhooks of all kinds of the group
+// with the manager.
+func SetupWebhooksWithManager(mgr ctrl.Manager) error {
+	for _, obj := range []runtime.Object{&NetworkGlobal{}, &Subnet{}, &Partition{}, &DualStackSubnet{}, &IPAddressClaim{}} {
+		if err := ctrl.NewWebhookManagedBy(mgr).For(obj).Complete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-network-onmetal-de-v1alpha1-networkglobal,mutating=false,failurePolicy=fail,groups=network.onmetal.de,resources=networkglobals,verbs=create;update,versions=v1alpha1,name=vnetworkglobal.network.onmetal.de
+// +kubebuilder:webhook:path=/validate-network-onmetal-de-v1alpha1-subnet,mutating=false,failurePolicy=fail,groups=network.onmetal.de,resources=subnets,verbs=create;update,versions=v1alpha1,name=vsubnet.network.onmetal.de
+// +kubebuilder:webhook:path=/validate-network-onmetal-de-v1alpha1-partition,mutating=false,failurePolicy=fail,groups=network.onmetal.de,resources=partitions,verbs=create;update,versions=v1alpha1,name=vpartition.network.onmetal.de
+// +kubebuilder:webhook:path=/validate-network-onmetal-de-v1alpha1-dualstacksubnet,mutating=false,failurePolicy=fail,groups=network.onmetal.de,resources=dualstacksubnets,verbs=create;update,versions=v1alpha1,name=vdualstacksubnet.network.onmetal.de
+// +kubebuilder:webhook:path=/validate-network-onmetal-de-v1alpha1-ipaddressclaim,mutating=false,failurePolicy=fail,groups=network.onmetal.de,resources=ipaddressclaims,verbs=create;update,versions=v1alpha1,name=vipaddressclaim.network.onmetal.de
+
+var (
+	_ webhook.Validator = &NetworkGlobal{}
+	_ webhook.Validator = &Subnet{}
+	_ webhook.Validator = &Partition{}
+	_ webhook.Validator = &DualStackSubnet{}
+	_ webhook.Validator = &IPAddressClaim{}
+)
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *NetworkGlobal) ValidateCreate() error {
+	return inGroup(legacyNetworkGlobal(r).Validate(nil))
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Finalizer changes must pass while the object is going away.
+func (r *NetworkGlobal) ValidateUpdate(old runtime.Object) error {
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	return inGroup(legacyNetworkGlobal(r).Validate(legacyNetworkGlobal(old.(*NetworkGlobal))))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *NetworkGlobal) ValidateDelete() error {
+	return nil
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Subnet) ValidateCreate() error {
+	return inGroup(legacySubnet(r).Validate(nil))
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Metadata changes must pass, or a Subnet whose references are gone could
+// never be deleted.
+func (r *Subnet) ValidateUpdate(old runtime.Object) error {
+	oldSubnet := old.(*Subnet)
+	if reflect.DeepEqual(oldSubnet.Spec, r.Spec) || !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	return inGroup(legacySubnet(r).Validate(legacySubnet(oldSubnet)))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Subnet) ValidateDelete() error {
+	return nil
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Partition) ValidateCreate() error {
+	return inGroup(legacyPartition(r).ValidateCreate())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Partition) ValidateUpdate(old runtime.Object) error {
+	return inGroup(legacyPartition(r).ValidateUpdate(legacyPartition(old.(*Partition))))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Partition) ValidateDelete() error {
+	return nil
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *DualStackSubnet) ValidateCreate() error {
+	return inGroup(legacyDualStackSubnet(r).ValidateCreate())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *DualStackSubnet) ValidateUpdate(old runtime.Object) error {
+	return inGroup(legacyDualStackSubnet(r).ValidateUpdate(legacyDualStackSubnet(old.(*DualStackSubnet))))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *DualStackSubnet) ValidateDelete() error {
+	return nil
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *IPAddressClaim) ValidateCreate() error {
+	return inGroup(legacyIPAddressClaim(r).ValidateCreate())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *IPAddressClaim) ValidateUpdate(old runtime.Object) error {
+	return inGroup(legacyIPAddressClaim(r).ValidateUpdate(legacyIPAddressClaim(old.(*IPAddressClaim))))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *IPAddressClaim) ValidateDelete() error {
+	return nil
+}
+
+// inGroup moves an Invalid error of a legacy kind into this group, so that the
+// message names the kind the user sent.
+func inGroup(err error) error {
+	statusErr, ok := err.(*apierrors.StatusError)
+	if !ok || statusErr.ErrStatus.Details == nil {
+		return err
+	}
+	details := statusErr.ErrStatus.Details
+	legacy := details.Kind + "." + details.Group
+	details.Group = GroupVersion.Group
+	statusErr.ErrStatus.Message = strings.Replace(statusErr.ErrStatus.Message, legacy, details.Kind+"."+details.Group, 1)
+	return statusErr
+}
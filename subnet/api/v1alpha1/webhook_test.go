@@ -0,0 +1,96 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	corev1 "gardener/subnet/api/v1"
+)
+
+// expectInvalid checks that err is an Invalid error of the kind in this group
+// complaining about the field.
+func expectInvalid(err error, kind, field string) {
+	ExpectWithOffset(1, apierrors.IsInvalid(err)).To(BeTrue(), "not an Invalid error: %v", err)
+	details := err.(*apierrors.StatusError).ErrStatus.Details
+	ExpectWithOffset(1, details.Group).To(Equal(GroupVersion.Group))
+	ExpectWithOffset(1, details.Kind).To(Equal(kind))
+	var fields []string
+	for _, cause := range details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	ExpectWithOffset(1, fields).To(ContainElement(field))
+	ExpectWithOffset(1, err.Error()).To(HavePrefix(kind + "." + GroupVersion.Group))
+}
+
+var _ = Describe("The webhooks", func() {
+	var subnet *Subnet
+
+	BeforeEach(func() {
+		subnet = &Subnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "subnet", Namespace: "default"},
+			Spec: corev1.SubnetSpec{
+				Type:            corev1.SubnetTypeIPv4,
+				CIDR:            "10.0.1.0/24",
+				NetworkGlobalID: "net",
+			},
+		}
+	})
+
+	It("accept a valid subnet", func() {
+		Expect(subnet.ValidateCreate()).To(Succeed())
+	})
+
+	It("reject a subnet the legacy webhook rejects", func() {
+		subnet.Spec.CIDR = "10.0.1.1/24"
+		expectInvalid(subnet.ValidateCreate(), "Subnet", "spec.cidr")
+	})
+
+	It("reject changes to the immutable fields of a subnet", func() {
+		updated := subnet.DeepCopy()
+		updated.Spec.NetworkGlobalID = "other"
+		expectInvalid(updated.ValidateUpdate(subnet), "Subnet", "spec.networkGlobalID")
+	})
+
+	It("let metadata changes of a subnet pass", func() {
+		subnet.Spec.CIDR = "10.0.1.1/24"
+		updated := subnet.DeepCopy()
+		updated.Finalizers = []string{SubnetFinalizer}
+		Expect(updated.ValidateUpdate(subnet)).To(Succeed())
+	})
+
+	It("reject a networkGlobal the legacy webhook rejects", func() {
+		nGlobal := &NetworkGlobal{
+			ObjectMeta: metav1.ObjectMeta{Name: "net", Namespace: "default"},
+			Spec:       netGlo.NetworkGlobalSpec{ID: "net", AddressSpaces: []string{"10.0.0.0/8", "10.1.0.0/16"}},
+		}
+		expectInvalid(nGlobal.ValidateCreate(), "NetworkGlobal", "spec.addressSpaces[1]")
+
+		nGlobal.Spec.AddressSpaces = nil
+		updated := nGlobal.DeepCopy()
+		updated.Spec.ID = "other"
+		expectInvalid(updated.ValidateUpdate(nGlobal), "NetworkGlobal", "spec.id")
+
+		now := metav1.Now()
+		updated.DeletionTimestamp = &now
+		Expect(updated.ValidateUpdate(nGlobal)).To(Succeed())
+	})
+})
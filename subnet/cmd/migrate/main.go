@@ -0,0 +1,82 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command migrate copies the objects of the legacy core.gardener.cloud/v1 and
+// core.core.gardener.cloud/v1 groups into the network.onmetal.de/v1alpha1
+// group. The finalizers of the legacy controllers are renamed to the ones the
+// manager keeps on the new group, and owner references are pointed at the
+// migrated owners. Objects that have been migrated before are left alone, so
+// the command can be run repeatedly while the legacy groups are still in use.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	_ = corev1.AddToScheme(scheme)
+	_ = netGlo.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+}
+
+func main() {
+	var namespace string
+	var dryRun bool
+	var releaseLegacyFinalizers bool
+	flag.StringVar(&namespace, "namespace", "", "The namespace to migrate, all namespaces if empty.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only log what would be migrated.")
+	flag.BoolVar(&releaseLegacyFinalizers, "release-legacy-finalizers", false,
+		"Remove the finalizers of the legacy controllers from the migrated legacy objects, "+
+			"so that they can be deleted. Only use this once the legacy controllers are stopped.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create the client")
+		os.Exit(1)
+	}
+
+	m := &migrator{
+		Client:                  c,
+		Log:                     ctrl.Log.WithName("migrate"),
+		Namespace:               namespace,
+		DryRun:                  dryRun,
+		ReleaseLegacyFinalizers: releaseLegacyFinalizers,
+	}
+	if err := m.Run(context.Background()); err != nil {
+		setupLog.Error(err, "migration failed")
+		os.Exit(1)
+	}
+}
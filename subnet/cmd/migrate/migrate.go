@@ -0,0 +1,260 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	netGloControllers "gardener/networkGlobal/controllers"
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
+	"gardener/subnet/controllers"
+)
+
+// finalizerRenames maps the finalizers of the legacy controllers to the ones
+// the manager keeps on the objects of the network.onmetal.de group.
+var finalizerRenames = map[string]string{
+	netGloControllers.NetworkGlobalFinalizerName: v1alpha1.NetworkGlobalFinalizer,
+	controllers.SubnetFinalizerName:              v1alpha1.SubnetFinalizer,
+	controllers.DeprecatedSubnetFinalizerName:    v1alpha1.SubnetFinalizer,
+}
+
+type object interface {
+	runtime.Object
+	metav1.Object
+}
+
+// migrator copies the legacy objects into the network.onmetal.de group.
+type migrator struct {
+	client.Client
+	Log logr.Logger
+
+	// Namespace limits the migration to one namespace, all if empty.
+	Namespace string
+	// DryRun only logs what would be migrated.
+	DryRun bool
+	// ReleaseLegacyFinalizers removes the legacy finalizers from migrated legacy objects.
+	ReleaseLegacyFinalizers bool
+}
+
+// Run migrates the NetworkGlobals, Partitions, DualStackSubnets, Subnets and
+// IPAddressClaims, in this order, and points the owner references of the
+// migrated Subnets at their migrated owners.
+func (m *migrator) Run(ctx context.Context) error {
+	nGlobals := &netGlo.NetworkGlobalList{}
+	if err := m.List(ctx, nGlobals, client.InNamespace(m.Namespace)); err != nil {
+		return err
+	}
+	for i := range nGlobals.Items {
+		legacy := &nGlobals.Items[i]
+		if err := m.migrate(ctx, "NetworkGlobal", legacy, v1alpha1.NetworkGlobalFromLegacy(legacy)); err != nil {
+			return err
+		}
+	}
+
+	partitions := &corev1.PartitionList{}
+	if err := m.List(ctx, partitions, client.InNamespace(m.Namespace)); err != nil {
+		return err
+	}
+	for i := range partitions.Items {
+		legacy := &partitions.Items[i]
+		if err := m.migrate(ctx, "Partition", legacy, v1alpha1.PartitionFromLegacy(legacy)); err != nil {
+			return err
+		}
+	}
+
+	dualStacks := &corev1.DualStackSubnetList{}
+	if err := m.List(ctx, dualStacks, client.InNamespace(m.Namespace)); err != nil {
+		return err
+	}
+	for i := range dualStacks.Items {
+		legacy := &dualStacks.Items[i]
+		if err := m.migrate(ctx, "DualStackSubnet", legacy, v1alpha1.DualStackSubnetFromLegacy(legacy)); err != nil {
+			return err
+		}
+	}
+
+	subnets := &corev1.SubnetList{}
+	if err := m.List(ctx, subnets, client.InNamespace(m.Namespace)); err != nil {
+		return err
+	}
+	for i := range subnets.Items {
+		legacy := &subnets.Items[i]
+		if err := m.migrate(ctx, "Subnet", legacy, v1alpha1.SubnetFromLegacy(legacy)); err != nil {
+			return err
+		}
+	}
+
+	claims := &corev1.IPAddressClaimList{}
+	if err := m.List(ctx, claims, client.InNamespace(m.Namespace)); err != nil {
+		return err
+	}
+	for i := range claims.Items {
+		legacy := &claims.Items[i]
+		if err := m.migrate(ctx, "IPAddressClaim", legacy, v1alpha1.IPAddressClaimFromLegacy(legacy)); err != nil {
+			return err
+		}
+	}
+
+	// The owners only exist in the new group once all objects have been
+	// copied, so the owner references are rewritten last.
+	for i := range subnets.Items {
+		if err := m.migrateOwnerReferences(ctx, &subnets.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate creates the migrated copy of the legacy object unless it exists
+// already, and releases the legacy finalizers if asked to.
+func (m *migrator) migrate(ctx context.Context, kind string, legacy, migrated object) error {
+	log := m.Log.WithValues("kind", kind, "namespace", legacy.GetNamespace(), "name", legacy.GetName())
+
+	if !legacy.GetDeletionTimestamp().IsZero() {
+		log.Info("Skipping the object, it is being deleted")
+		return nil
+	}
+
+	err := m.Get(ctx, client.ObjectKey{Namespace: migrated.GetNamespace(), Name: migrated.GetName()}, migrated.DeepCopyObject())
+	switch {
+	case err == nil:
+		log.Info("Already migrated")
+	case !apierrors.IsNotFound(err):
+		return err
+	case m.DryRun:
+		log.Info("Would migrate the object", "finalizers", renamedFinalizers(legacy.GetFinalizers()))
+		return nil
+	default:
+		migrated.SetFinalizers(renamedFinalizers(legacy.GetFinalizers()))
+		// Create drops the status, it is written through the status subresource afterwards.
+		withStatus := migrated.DeepCopyObject().(object)
+		if err := m.Create(ctx, migrated); err != nil {
+			return err
+		}
+		withStatus.SetUID(migrated.GetUID())
+		withStatus.SetResourceVersion(migrated.GetResourceVersion())
+		if err := m.Status().Update(ctx, withStatus); err != nil {
+			return err
+		}
+		log.Info("Migrated the object")
+	}
+
+	if m.ReleaseLegacyFinalizers && !m.DryRun {
+		return m.releaseLegacyFinalizers(ctx, legacy)
+	}
+	return nil
+}
+
+// releaseLegacyFinalizers removes the finalizers of the legacy controllers
+// from the legacy object.
+func (m *migrator) releaseLegacyFinalizers(ctx context.Context, legacy object) error {
+	finalizers := withoutLegacyFinalizers(legacy.GetFinalizers())
+	if len(finalizers) == len(legacy.GetFinalizers()) {
+		return nil
+	}
+	patch := client.MergeFrom(legacy.DeepCopyObject())
+	legacy.SetFinalizers(finalizers)
+	return client.IgnoreNotFound(m.Patch(ctx, legacy, patch))
+}
+
+// migrateOwnerReferences copies the owner references of the legacy Subnet to
+// the migrated one, pointing them at the migrated owners.
+func (m *migrator) migrateOwnerReferences(ctx context.Context, legacy *corev1.Subnet) error {
+	if len(legacy.OwnerReferences) == 0 || m.DryRun {
+		return nil
+	}
+	migrated := &v1alpha1.Subnet{}
+	if err := m.Get(ctx, client.ObjectKey{Namespace: legacy.Namespace, Name: legacy.Name}, migrated); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	var refs []metav1.OwnerReference
+	for _, ref := range legacy.OwnerReferences {
+		var owner object
+		switch {
+		case ref.APIVersion == corev1.GroupVersion.String() && ref.Kind == "Subnet":
+			owner = &v1alpha1.Subnet{}
+		case ref.APIVersion == corev1.GroupVersion.String() && ref.Kind == "DualStackSubnet":
+			owner = &v1alpha1.DualStackSubnet{}
+		case ref.APIVersion == netGlo.GroupVersion.String() && ref.Kind == "NetworkGlobal":
+			owner = &v1alpha1.NetworkGlobal{}
+		default:
+			refs = append(refs, ref)
+			continue
+		}
+		err := m.Get(ctx, client.ObjectKey{Namespace: legacy.Namespace, Name: ref.Name}, owner)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		ref.APIVersion = v1alpha1.GroupVersion.String()
+		ref.UID = owner.GetUID()
+		refs = append(refs, ref)
+	}
+	if equality.Semantic.DeepEqual(refs, migrated.OwnerReferences) {
+		return nil
+	}
+
+	patch := client.MergeFrom(migrated.DeepCopy())
+	migrated.OwnerReferences = refs
+	if err := m.Patch(ctx, migrated, patch); err != nil {
+		return err
+	}
+	m.Log.Info("Migrated the owner references", "kind", "Subnet", "namespace", migrated.Namespace, "name", migrated.Name)
+	return nil
+}
+
+// renamedFinalizers renames the finalizers of the legacy controllers and keeps
+// all others.
+func renamedFinalizers(finalizers []string) []string {
+	var renamed []string
+	seen := sets.NewString()
+	for _, finalizer := range finalizers {
+		if name, ok := finalizerRenames[finalizer]; ok {
+			finalizer = name
+		}
+		if !seen.Has(finalizer) {
+			seen.Insert(finalizer)
+			renamed = append(renamed, finalizer)
+		}
+	}
+	return renamed
+}
+
+// withoutLegacyFinalizers drops the finalizers of the legacy controllers and
+// keeps all others.
+func withoutLegacyFinalizers(finalizers []string) []string {
+	var kept []string
+	for _, finalizer := range finalizers {
+		if _, ok := finalizerRenames[finalizer]; !ok {
+			kept = append(kept, finalizer)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,238 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	netGloControllers "gardener/networkGlobal/controllers"
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
+	"gardener/subnet/controllers"
+)
+
+// uidClient hands out UIDs on create, which the fake client doesn't.
+type uidClient struct {
+	client.Client
+}
+
+func (c uidClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if accessor, ok := obj.(metav1.Object); ok {
+		accessor.SetUID(types.UID("uid-" + accessor.GetName()))
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("The migration", func() {
+	const keptFinalizer = "example.com/keep"
+
+	var (
+		ctx       = context.Background()
+		nGlobal   *netGlo.NetworkGlobal
+		dualStack *corev1.DualStackSubnet
+		subnet    *corev1.Subnet
+		claim     *corev1.IPAddressClaim
+		legacy    []runtime.Object
+		m         *migrator
+	)
+
+	run := func() {
+		m.Client = uidClient{fake.NewFakeClientWithScheme(clientgoscheme.Scheme, legacy...)}
+		ExpectWithOffset(1, m.Run(ctx)).To(Succeed())
+	}
+	get := func(obj runtime.Object, name string) error {
+		return m.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, obj)
+	}
+
+	BeforeEach(func() {
+		// The fake client decodes through the client-go scheme.
+		Expect(corev1.AddToScheme(clientgoscheme.Scheme)).To(Succeed())
+		Expect(netGlo.AddToScheme(clientgoscheme.Scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(clientgoscheme.Scheme)).To(Succeed())
+
+		nGlobal = &netGlo.NetworkGlobal{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "net",
+				Namespace:  "default",
+				Finalizers: []string{netGloControllers.NetworkGlobalFinalizerName, keptFinalizer},
+			},
+			Spec: netGlo.NetworkGlobalSpec{ID: "net", AddressSpaces: []string{"10.0.0.0/16"}},
+			Status: netGlo.NetworkGlobalStatus{
+				ObservedGeneration: 1,
+				Conditions: []netGlo.NetworkGlobalCondition{{
+					Type:   netGlo.NetworkGlobalValid,
+					Status: metav1.ConditionTrue,
+					Reason: "Valid",
+				}},
+			},
+		}
+		dualStack = &corev1.DualStackSubnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "segment", Namespace: "default", UID: "legacy-segment"},
+		}
+		subnet = &corev1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "segment-ipv4",
+				Namespace:  "default",
+				Labels:     map[string]string{corev1.DualStackSubnetLabel: "segment"},
+				Finalizers: []string{controllers.SubnetFinalizerName, controllers.DeprecatedSubnetFinalizerName},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: corev1.GroupVersion.String(),
+					Kind:       "DualStackSubnet",
+					Name:       "segment",
+					UID:        "legacy-segment",
+				}, {
+					APIVersion: "example.com/v1",
+					Kind:       "Keeper",
+					Name:       "keeper",
+					UID:        "keeper",
+				}},
+			},
+			Spec: corev1.SubnetSpec{
+				Type:            corev1.SubnetTypeIPv4,
+				CIDR:            "10.0.1.0/24",
+				NetworkGlobalID: "net",
+			},
+			Status: corev1.SubnetStatus{
+				State:   corev1.SubnetStateValid,
+				Gateway: "10.0.1.1",
+			},
+		}
+		claim = &corev1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "other"},
+			Spec:       corev1.IPAddressClaimSpec{SubnetID: "elsewhere"},
+		}
+		legacy = []runtime.Object{nGlobal, dualStack, subnet, claim}
+		m = &migrator{Log: ctrl.Log.WithName("migrate")}
+	})
+
+	It("renames the finalizers of the legacy controllers and keeps the others", func() {
+		run()
+
+		migratedNGlobal := &v1alpha1.NetworkGlobal{}
+		Expect(get(migratedNGlobal, "net")).To(Succeed())
+		Expect(migratedNGlobal.Finalizers).To(Equal([]string{v1alpha1.NetworkGlobalFinalizer, keptFinalizer}))
+
+		migratedSubnet := &v1alpha1.Subnet{}
+		Expect(get(migratedSubnet, "segment-ipv4")).To(Succeed())
+		Expect(migratedSubnet.Finalizers).To(Equal([]string{v1alpha1.SubnetFinalizer}))
+
+		By("leaving the legacy finalizers in place")
+		released := &netGlo.NetworkGlobal{}
+		Expect(get(released, "net")).To(Succeed())
+		Expect(released.Finalizers).To(ConsistOf(netGloControllers.NetworkGlobalFinalizerName, keptFinalizer))
+	})
+
+	It("copies the spec and the status", func() {
+		run()
+
+		migratedNGlobal := &v1alpha1.NetworkGlobal{}
+		Expect(get(migratedNGlobal, "net")).To(Succeed())
+		Expect(migratedNGlobal.Spec).To(Equal(nGlobal.Spec))
+		Expect(equality.Semantic.DeepEqual(migratedNGlobal.Status, nGlobal.Status)).To(BeTrue())
+
+		migratedSubnet := &v1alpha1.Subnet{}
+		Expect(get(migratedSubnet, "segment-ipv4")).To(Succeed())
+		Expect(migratedSubnet.Labels).To(Equal(subnet.Labels))
+		Expect(migratedSubnet.Spec).To(Equal(subnet.Spec))
+		Expect(equality.Semantic.DeepEqual(migratedSubnet.Status, subnet.Status)).To(BeTrue())
+	})
+
+	It("points the owner references at the migrated owners", func() {
+		run()
+
+		migratedSubnet := &v1alpha1.Subnet{}
+		Expect(get(migratedSubnet, "segment-ipv4")).To(Succeed())
+		Expect(migratedSubnet.OwnerReferences).To(Equal([]metav1.OwnerReference{{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "DualStackSubnet",
+			Name:       "segment",
+			UID:        "uid-segment",
+		}, subnet.OwnerReferences[1]}))
+	})
+
+	It("leaves objects that have been migrated before alone", func() {
+		legacy = append(legacy, &v1alpha1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "segment-ipv4", Namespace: "default"},
+			Spec:       corev1.SubnetSpec{CIDR: "10.0.2.0/24"},
+		})
+		run()
+
+		migratedSubnet := &v1alpha1.Subnet{}
+		Expect(get(migratedSubnet, "segment-ipv4")).To(Succeed())
+		Expect(migratedSubnet.Spec.CIDR).To(Equal("10.0.2.0/24"))
+		Expect(migratedSubnet.Finalizers).To(BeEmpty())
+	})
+
+	It("only migrates the namespace it is limited to", func() {
+		m.Namespace = "other"
+		run()
+
+		Expect(apierrors.IsNotFound(get(&v1alpha1.NetworkGlobal{}, "net"))).To(BeTrue())
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "other", Name: "claim"}, &v1alpha1.IPAddressClaim{})).To(Succeed())
+	})
+
+	It("skips objects that are being deleted", func() {
+		now := metav1.Now()
+		subnet.DeletionTimestamp = &now
+		run()
+
+		Expect(apierrors.IsNotFound(get(&v1alpha1.Subnet{}, "segment-ipv4"))).To(BeTrue())
+		Expect(get(&v1alpha1.NetworkGlobal{}, "net")).To(Succeed())
+	})
+
+	It("creates nothing on a dry run", func() {
+		m.DryRun = true
+		m.ReleaseLegacyFinalizers = true
+		run()
+
+		Expect(apierrors.IsNotFound(get(&v1alpha1.NetworkGlobal{}, "net"))).To(BeTrue())
+		Expect(apierrors.IsNotFound(get(&v1alpha1.Subnet{}, "segment-ipv4"))).To(BeTrue())
+		released := &netGlo.NetworkGlobal{}
+		Expect(get(released, "net")).To(Succeed())
+		Expect(released.Finalizers).To(ConsistOf(netGloControllers.NetworkGlobalFinalizerName, keptFinalizer))
+	})
+
+	It("releases the legacy finalizers if asked to", func() {
+		m.ReleaseLegacyFinalizers = true
+		run()
+
+		releasedNGlobal := &netGlo.NetworkGlobal{}
+		Expect(get(releasedNGlobal, "net")).To(Succeed())
+		Expect(releasedNGlobal.Finalizers).To(Equal([]string{keptFinalizer}))
+		releasedSubnet := &corev1.Subnet{}
+		Expect(get(releasedSubnet, "segment-ipv4")).To(Succeed())
+		Expect(releasedSubnet.Finalizers).To(BeEmpty())
+
+		By("keeping the finalizers of the migrated objects")
+		migratedSubnet := &v1alpha1.Subnet{}
+		Expect(get(migratedSubnet, "segment-ipv4")).To(Succeed())
+		Expect(migratedSubnet.Finalizers).To(Equal([]string{v1alpha1.SubnetFinalizer}))
+	})
+})
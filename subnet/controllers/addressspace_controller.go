@@ -0,0 +1,169 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+// AddressSpaceReconciler reports how much of the address spaces of a
+// NetworkGlobal is taken by its top-level Subnets.
+type AddressSpaceReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.core.gardener.cloud,resources=networkglobals,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.core.gardener.cloud,resources=networkglobals/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets,verbs=get;list;watch
+
+func (r *AddressSpaceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("networkglobal", req.NamespacedName)
+
+	nGlobal := &netGlo.NetworkGlobal{}
+	if err := r.Get(ctx, req.NamespacedName, nGlobal); err != nil {
+		if apierrors.IsNotFound(err) {
+			forgetNetworkGlobalMetrics(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !nGlobal.DeletionTimestamp.IsZero() {
+		forgetNetworkGlobalMetrics(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	capacity := map[string]*big.Int{}
+	for _, space := range nGlobal.Spec.AddressSpaces {
+		if spaceNet, err := ipam.ParseCIDR(space); err == nil {
+			family := ipam.Family(spaceNet)
+			if capacity[family] == nil {
+				capacity[family] = new(big.Int)
+			}
+			capacity[family].Add(capacity[family], ipam.All(spaceNet).Size())
+		}
+	}
+
+	subnets, err := indexedSubnets(ctx, r, nGlobal.Namespace, corev1.NetworkGlobalIDField, nGlobal.Spec.ID)
+	if err != nil {
+		log.Error(err, "Couldn't list the subnets", "NetworkGlobal", nGlobal.Name)
+		return ctrl.Result{}, err
+	}
+	used := map[string]*big.Int{corev1.SubnetTypeIPv4: new(big.Int), corev1.SubnetTypeIPv6: new(big.Int)}
+	for _, subnet := range subnets {
+		if subnet.Spec.SubnetParentID != "" || subnet.Status.State != corev1.SubnetStateValid {
+			continue
+		}
+		if ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR); err == nil {
+			used[ipam.Family(ipNet)].Add(used[ipam.Family(ipNet)], ipam.All(ipNet).Size())
+		}
+	}
+
+	status := nGlobal.Status.DeepCopy()
+	var exhaustedFamilies []string
+	for _, family := range []string{corev1.SubnetTypeIPv4, corev1.SubnetTypeIPv6} {
+		usage := status.Family(family)
+		if capacity[family] == nil {
+			*usage = netGlo.AddressUsage{}
+			forgetNetworkGlobalMetrics(req.NamespacedName, family)
+			continue
+		}
+		reportNetworkGlobalMetrics(nGlobal, family, capacity[family], used[family])
+		usage.Capacity = addressQuantity(capacity[family])
+		usage.Used = addressQuantity(used[family])
+		usage.DisplayCapacity = ipam.FormatSize(capacity[family], family)
+		usage.DisplayUsed = ipam.FormatSize(used[family], family)
+		if capacity[family].Sign() > 0 && used[family].Cmp(capacity[family]) >= 0 {
+			exhaustedFamilies = append(exhaustedFamilies, family)
+		}
+	}
+
+	exhausted := netGlo.NetworkGlobalCondition{
+		Type:               netGlo.NetworkGlobalExhausted,
+		Status:             metav1.ConditionFalse,
+		Reason:             "SpaceLeft",
+		ObservedGeneration: nGlobal.Generation,
+	}
+	if len(exhaustedFamilies) > 0 {
+		exhausted.Status, exhausted.Reason = metav1.ConditionTrue, "NoSpaceLeft"
+		exhausted.Message = fmt.Sprintf("top-level subnets take all of the %s address spaces", strings.Join(exhaustedFamilies, " and "))
+	}
+	status.SetCondition(exhausted)
+	if equality.Semantic.DeepEqual(*status, nGlobal.Status) {
+		return ctrl.Result{}, nil
+	}
+	nGlobal.Status = *status
+	if err := r.Status().Update(ctx, nGlobal); err != nil {
+		log.Error(err, "Couldn't update the status", "NetworkGlobal", nGlobal.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *AddressSpaceReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// The NetworkGlobal controller runs in the same manager and reconciles
+	// NetworkGlobals as well, so this one needs a name of its own.
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("addressspace").
+		For(&netGlo.NetworkGlobal{}).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &corev1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.subnetNetworkGlobalRequests),
+		}).
+		Complete(r)
+}
+
+// subnetNetworkGlobalRequests maps a top-level Subnet to a request for its
+// NetworkGlobal, whose usage depends on it.
+func (r *AddressSpaceReconciler) subnetNetworkGlobalRequests(a handler.MapObject) []reconcile.Request {
+	subnet, ok := a.Object.(*corev1.Subnet)
+	if !ok || subnet.Spec.NetworkGlobalID == "" || subnet.Spec.SubnetParentID != "" {
+		return nil
+	}
+	nGlobal, err := getNetworkGlobal(context.Background(), r, subnet.Namespace, subnet.Spec.NetworkGlobalID)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			r.Log.Error(err, "Couldn't look up the NetworkGlobal", "Subnet", subnet.Name)
+		}
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{
+		Name:      nGlobal.Name,
+		Namespace: nGlobal.Namespace,
+	}}}
+}
@@ -0,0 +1,184 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"math/big"
+	"net"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// subnetCapacity returns the number of usable addresses of the Subnet and how
+// many of them are still free.
+func (r *SubnetReconciler) subnetCapacity(ctx context.Context, subnet *corev1.Subnet) (*big.Int, *big.Int, error) {
+	usable, err := usableAddresses(subnet)
+	if err != nil {
+		return new(big.Int), new(big.Int), nil
+	}
+
+	free, err := freeAddresses(ctx, r, subnet)
+	if err != nil {
+		return nil, nil, err
+	}
+	return usable.Size(), free.Size(), nil
+}
+
+// usableAddresses returns the addresses of the Subnet that can be handed out to
+// hosts, leaving out its gateway, reserved ranges and DHCP pools.
+func usableAddresses(subnet *corev1.Subnet) (*ipam.Set, error) {
+	ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	usable := ipam.Usable(ipNet)
+	if gateway := effectiveGateway(subnet); gateway != nil {
+		usable.RemoveIP(gateway)
+	}
+	removeReservedRanges(usable, subnet)
+	return usable, nil
+}
+
+// removeReservedRanges removes the reserved ranges and the DHCP pools of the
+// Subnet from the set. Ranges that don't parse are skipped, the webhook rejects
+// them.
+func removeReservedRanges(set *ipam.Set, subnet *corev1.Subnet) {
+	for _, r := range subnet.Spec.ExcludedRanges() {
+		if first, last, err := ipam.ParseRange(r.Start, r.End); err == nil {
+			set.RemoveRange(first, last)
+		}
+	}
+}
+
+// maxFreeBlocks bounds the number of free blocks listed in the status.
+const maxFreeBlocks = 8
+
+// subnetFreeBlocks returns the largest blocks of the Subnet that a child could
+// still be carved from, and a summary of all of them.
+func (r *SubnetReconciler) subnetFreeBlocks(ctx context.Context, subnet *corev1.Subnet) ([]string, string, error) {
+	if _, err := ipam.ParseCIDR(subnet.Spec.CIDR); err != nil {
+		return nil, "", nil
+	}
+
+	free, err := unallocatedBlocks(ctx, r, subnet)
+	if err != nil {
+		return nil, "", err
+	}
+	var blocks []string
+	for _, block := range free.LargestBlocks(maxFreeBlocks) {
+		blocks = append(blocks, block.String())
+	}
+	return blocks, ipam.SummarizeBlocks(free.Blocks(), 2), nil
+}
+
+// freeAddresses returns the usable addresses of the Subnet that are neither
+// reserved, part of a valid child Subnet nor handed out to an IPAddressClaim.
+func freeAddresses(ctx context.Context, c client.Reader, subnet *corev1.Subnet) (*ipam.Set, error) {
+	free, err := usableAddresses(subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := childSubnets(ctx, c, subnet)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if child.Status.State == corev1.SubnetStateInvalid {
+			continue
+		}
+		childNet, err := ipam.ParseCIDR(child.Spec.CIDR)
+		if err != nil {
+			continue
+		}
+		free.RemovePrefix(childNet)
+	}
+
+	claims, err := subnetClaims(ctx, c, subnet)
+	if err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		for _, address := range []string{claim.Status.Address, claim.Status.IPv6Address} {
+			if ip := net.ParseIP(address); ip != nil {
+				free.RemoveIP(ip)
+			}
+		}
+	}
+	return free, nil
+}
+
+// subnetClaims returns the IPAddressClaims taking their address from the
+// Subnet, directly or through the DualStackSubnet the Subnet belongs to.
+func subnetClaims(ctx context.Context, c client.Reader, subnet *corev1.Subnet) ([]corev1.IPAddressClaim, error) {
+	claims := &corev1.IPAddressClaimList{}
+	if err := c.List(ctx, claims, client.InNamespace(subnet.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var matches []corev1.IPAddressClaim
+	for _, claim := range claims.Items {
+		if claimTakesFrom(&claim.Spec, subnet) {
+			matches = append(matches, claim)
+		}
+	}
+	return matches, nil
+}
+
+// claimTakesFrom reports whether the claim takes its address from the Subnet,
+// directly or through the DualStackSubnet the Subnet belongs to.
+func claimTakesFrom(claim *corev1.IPAddressClaimSpec, subnet metav1.Object) bool {
+	dualStack := subnet.GetLabels()[corev1.DualStackSubnetLabel]
+	return claim.SubnetID == subnet.GetName() || (dualStack != "" && claim.DualStackSubnetID == dualStack)
+}
+
+// addressQuantity returns n as a quantity for the status. Quantities are not
+// limited in size, so the capacity of IPv6 subnets doesn't overflow.
+func addressQuantity(n *big.Int) resource.Quantity {
+	q, err := resource.ParseQuantity(n.String())
+	if err != nil {
+		return resource.Quantity{}
+	}
+	return q
+}
+
+// quantityToInt returns the number of addresses held by a status quantity.
+func quantityToInt(q resource.Quantity) *big.Int {
+	dec := q.AsDec()
+	n := new(big.Int).Set(dec.UnscaledBig())
+	if scale := int64(dec.Scale()); scale > 0 {
+		n.Quo(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil))
+	} else if scale < 0 {
+		n.Mul(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(-scale), nil))
+	}
+	return n
+}
+
+// subnetFamily returns the address family of the Subnet, taken from the CIDR
+// if it parses and from the type otherwise.
+func subnetFamily(subnet *corev1.Subnet) string {
+	if ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR); err == nil {
+		return ipam.Family(ipNet)
+	}
+	return subnet.Spec.Type
+}
@@ -0,0 +1,128 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// needsCarving reports whether the Subnet waits for a block of its parent.
+func needsCarving(subnet *corev1.Subnet) bool {
+	return subnet.Spec.CIDR == "" && subnet.Spec.PrefixLength != 0 && subnet.Spec.SubnetParentID != ""
+}
+
+// carveSubnet picks the lowest free block of the requested prefix length in the
+// parent Subnet, outside the reserved ranges of the Partition, and writes it
+// into the spec of the Subnet. It leaves the Subnet untouched if the parent
+// isn't valid or has no such block left, and returns the problem keeping the
+// Subnet pending if it isn't one checkSubnetHierarchy reports.
+func (r *SubnetReconciler) carveSubnet(ctx context.Context, subnet *corev1.Subnet) (*subnetProblem, error) {
+	parentKey := client.ObjectKey{Name: subnet.Spec.SubnetParentID, Namespace: subnet.Namespace}
+	unlock := allocationLocks.lock(parentKey)
+	defer unlock()
+
+	parent := &corev1.Subnet{}
+	if err := r.APIReader.Get(ctx, parentKey, parent); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if parent.Status.State != corev1.SubnetStateValid || !parent.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	free, err := unallocatedBlocks(ctx, r.APIReader, parent)
+	if err != nil {
+		return nil, err
+	}
+	if subnet.Spec.PartitionID != "" {
+		partition := &corev1.Partition{}
+		err := r.APIReader.Get(ctx, client.ObjectKey{Name: subnet.Spec.PartitionID, Namespace: subnet.Namespace}, partition)
+		if apierrors.IsNotFound(err) {
+			return pendingSubnet(corev1.SubnetReasonPartitionNotFound,
+				"partition %q doesn't exist", subnet.Spec.PartitionID), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, reserved := range partition.Spec.ReservedRanges {
+			if reservedNet, err := ipam.ParseCIDR(reserved); err == nil {
+				free.RemovePrefix(reservedNet)
+			}
+		}
+	}
+	block := free.FirstBlock(subnet.Spec.PrefixLength)
+	if block == nil || (subnet.Spec.Type != "" && subnet.Spec.Type != ipam.Family(block)) {
+		return nil, nil
+	}
+
+	patch := client.MergeFrom(subnet.DeepCopy())
+	subnet.Spec.CIDR = block.String()
+	if subnet.Spec.Type == "" {
+		subnet.Spec.Type = ipam.Family(block)
+	}
+	if err := r.Patch(ctx, subnet, patch); err != nil {
+		return nil, err
+	}
+	r.Log.Info("Carved the Subnet out of its parent", "Subnet", subnet.Name, "Parent", parent.Name, "CIDR", subnet.Spec.CIDR)
+	r.Recorder.Eventf(subnet, corev1api.EventTypeNormal, "Carved", "Carved %s out of parent subnet %q", subnet.Spec.CIDR, parent.Name)
+	return nil, nil
+}
+
+// unallocatedBlocks returns the addresses of the Subnet that are neither
+// reserved, part of a child Subnet nor handed out to an IPAddressClaim. Unlike
+// freeAddresses it starts from the whole prefix and also skips invalid
+// children, since the webhook wouldn't let a carved block overlap them either.
+func unallocatedBlocks(ctx context.Context, c client.Reader, subnet *corev1.Subnet) (*ipam.Set, error) {
+	ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	free := ipam.All(ipNet)
+	removeReservedRanges(free, subnet)
+
+	children, err := childSubnets(ctx, c, subnet)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		childNet, err := ipam.ParseCIDR(child.Spec.CIDR)
+		if err != nil {
+			continue
+		}
+		free.RemovePrefix(childNet)
+	}
+
+	claims, err := subnetClaims(ctx, c, subnet)
+	if err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		for _, address := range []string{claim.Status.Address, claim.Status.IPv6Address} {
+			if ip := net.ParseIP(address); ip != nil {
+				free.RemoveIP(ip)
+			}
+		}
+	}
+	return free, nil
+}
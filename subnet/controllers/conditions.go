@@ -0,0 +1,144 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// subnetConditions derives the conditions of the Subnet from the outcome of
+// checkSubnet and its capacity.
+func (r *SubnetReconciler) subnetConditions(ctx context.Context, subnet *corev1.Subnet, problem *subnetProblem, capacity, left *big.Int) ([]corev1.SubnetCondition, error) {
+	condition := func(t corev1.SubnetConditionType, status metav1.ConditionStatus, reason, message string) corev1.SubnetCondition {
+		return corev1.SubnetCondition{
+			Type:               t,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: subnet.Generation,
+		}
+	}
+	var conditions []corev1.SubnetCondition
+
+	ok, err := r.IsNetworkGlobalIDValid(subnet)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		conditions = append(conditions, condition(corev1.SubnetNetworkGlobalResolved, metav1.ConditionTrue, "Resolved", ""))
+	} else if networkGlobalGone(subnet) {
+		// The NetworkGlobal was resolved before, so it was deleted underneath the subnet.
+		conditions = append(conditions, condition(corev1.SubnetNetworkGlobalResolved, metav1.ConditionFalse,
+			corev1.SubnetReasonNetworkGlobalGone, fmt.Sprintf("networkGlobal %q has disappeared", subnet.Spec.NetworkGlobalID)))
+	} else {
+		conditions = append(conditions, condition(corev1.SubnetNetworkGlobalResolved, metav1.ConditionFalse,
+			corev1.SubnetReasonNetworkGlobalNotFound, fmt.Sprintf("networkGlobal %q doesn't exist", subnet.Spec.NetworkGlobalID)))
+	}
+
+	if parentName := subnet.Spec.SubnetParentID; parentName == "" {
+		conditions = append(conditions, condition(corev1.SubnetParentResolved, metav1.ConditionTrue, "NoParent", ""))
+	} else {
+		err := r.Get(ctx, client.ObjectKey{Name: parentName, Namespace: subnet.Namespace}, &corev1.Subnet{})
+		switch {
+		case apierrors.IsNotFound(err):
+			conditions = append(conditions, condition(corev1.SubnetParentResolved, metav1.ConditionFalse,
+				corev1.SubnetReasonParentNotFound, fmt.Sprintf("parent subnet %q doesn't exist", parentName)))
+		case err != nil:
+			return nil, err
+		default:
+			conditions = append(conditions, condition(corev1.SubnetParentResolved, metav1.ConditionTrue, "Resolved", ""))
+		}
+	}
+
+	switch {
+	case problem == nil:
+		conditions = append(conditions,
+			condition(corev1.SubnetValid, metav1.ConditionTrue, "Valid", ""),
+			condition(corev1.SubnetReady, metav1.ConditionTrue, "Ready", ""))
+	case problem.state == corev1.SubnetStateInvalid:
+		conditions = append(conditions,
+			condition(corev1.SubnetValid, metav1.ConditionFalse, problem.reason, problem.message),
+			condition(corev1.SubnetReady, metav1.ConditionFalse, problem.reason, problem.message))
+	default:
+		conditions = append(conditions,
+			condition(corev1.SubnetValid, metav1.ConditionUnknown, problem.reason, problem.message),
+			condition(corev1.SubnetReady, metav1.ConditionFalse, problem.reason, problem.message))
+	}
+
+	if capacity.Sign() > 0 && left.Sign() == 0 {
+		conditions = append(conditions, condition(corev1.SubnetExhausted, metav1.ConditionTrue, "NoAddressesLeft",
+			"all addresses are taken by child subnets or address claims"))
+	} else {
+		conditions = append(conditions, condition(corev1.SubnetExhausted, metav1.ConditionFalse, "AddressesLeft", ""))
+	}
+
+	claim, err := gatewayClaim(ctx, r, subnet)
+	if err != nil {
+		return nil, err
+	}
+	if claim != nil {
+		conditions = append(conditions, condition(corev1.SubnetGatewayConflict, metav1.ConditionTrue, "GatewayClaimed",
+			fmt.Sprintf("address claim %q holds the gateway address %s", claim.Name, effectiveGateway(subnet))))
+	} else {
+		conditions = append(conditions, condition(corev1.SubnetGatewayConflict, metav1.ConditionFalse, "GatewayFree", ""))
+	}
+	return conditions, nil
+}
+
+// gatewayClaim returns the IPAddressClaim bound to the gateway address of the
+// Subnet, or nil if the gateway is free. Claims are kept off the gateway, but
+// ones bound before that may hold the first usable host.
+func gatewayClaim(ctx context.Context, c client.Reader, subnet *corev1.Subnet) (*corev1.IPAddressClaim, error) {
+	gateway := effectiveGateway(subnet)
+	if gateway == nil {
+		return nil, nil
+	}
+	claims, err := subnetClaims(ctx, c, subnet)
+	if err != nil {
+		return nil, err
+	}
+	for i, claim := range claims {
+		if claim.Status.State != corev1.IPAddressClaimStateBound {
+			continue
+		}
+		for _, address := range []string{claim.Status.Address, claim.Status.IPv6Address} {
+			if ip := net.ParseIP(address); ip != nil && ip.Equal(gateway) {
+				return &claims[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// networkGlobalGone reports whether the NetworkGlobal of the subnet was
+// resolved for the current spec before and has disappeared since.
+func networkGlobalGone(subnet *corev1.Subnet) bool {
+	resolved := subnet.Status.GetCondition(corev1.SubnetNetworkGlobalResolved)
+	if resolved == nil || resolved.ObservedGeneration != subnet.Generation {
+		return false
+	}
+	return resolved.Status == metav1.ConditionTrue || resolved.Reason == corev1.SubnetReasonNetworkGlobalGone
+}
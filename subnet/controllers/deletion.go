@@ -0,0 +1,104 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// maxListedDependents bounds the number of names put into a condition message.
+const maxListedDependents = 10
+
+// subnetDependents returns the names of the child Subnets and IPAddressClaims
+// that keep the Subnet from being deleted.
+func (r *SubnetReconciler) subnetDependents(ctx context.Context, subnet *corev1.Subnet) ([]string, []string, error) {
+	children, err := indexedSubnets(ctx, r, subnet.Namespace, corev1.SubnetParentIDField, subnet.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	claims, err := subnetClaims(ctx, r, subnet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var childNames, claimNames []string
+	for _, child := range children {
+		childNames = append(childNames, child.Name)
+	}
+	for _, claim := range claims {
+		claimNames = append(claimNames, claim.Name)
+	}
+	return childNames, claimNames, nil
+}
+
+// blockDeletion records on the Subnet which dependents keep it from being
+// deleted and emits a warning event the first time it does so.
+func (r *SubnetReconciler) blockDeletion(ctx context.Context, subnet *corev1.Subnet, children, claims []string) error {
+	message, blocked, changed := setDeletionBlocked(&subnet.Status, subnet.Generation, children, claims)
+	if !changed {
+		return nil
+	}
+	if blocked {
+		r.Recorder.Event(subnet, corev1api.EventTypeWarning, "DeletionBlocked", message)
+	}
+	return r.Status().Update(ctx, subnet)
+}
+
+// setDeletionBlocked sets the conditions of a deleted Subnet whose dependents
+// still exist. It returns the message of the conditions, whether the
+// DeletionBlocked condition has just been set and whether anything changed.
+func setDeletionBlocked(status *corev1.SubnetStatus, generation int64, children, claims []string) (string, bool, bool) {
+	var waiting []string
+	if len(children) > 0 {
+		waiting = append(waiting, "child subnets "+listNames(children))
+	}
+	if len(claims) > 0 {
+		waiting = append(waiting, "address claims "+listNames(claims))
+	}
+	message := fmt.Sprintf("waiting for %s to be deleted", strings.Join(waiting, " and "))
+
+	blocked := status.SetCondition(corev1.SubnetCondition{
+		Type:               corev1.SubnetDeletionBlocked,
+		Status:             metav1.ConditionTrue,
+		Reason:             "DependentsExist",
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+	notReady := status.SetCondition(corev1.SubnetCondition{
+		Type:               corev1.SubnetReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Deleting",
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+	return message, blocked, blocked || notReady
+}
+
+// listNames joins the names, cutting the list short after maxListedDependents.
+func listNames(names []string) string {
+	if len(names) > maxListedDependents {
+		names = append(names[:maxListedDependents:maxListedDependents], fmt.Sprintf("and %d more", len(names)-maxListedDependents))
+	}
+	return strings.Join(names, ", ")
+}
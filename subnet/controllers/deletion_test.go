@@ -0,0 +1,145 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+var _ = Describe("NetworkGlobal deletion", func() {
+	ctx := context.Background()
+
+	networkGlobalExists := func(name string) func() bool {
+		return func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: testNamespace}, &netGlo.NetworkGlobal{})
+			return err == nil
+		}
+	}
+	deletionBlocked := func(name string) func() metav1.ConditionStatus {
+		return func() metav1.ConditionStatus {
+			nGlobal := &netGlo.NetworkGlobal{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: testNamespace}, nGlobal); err != nil {
+				return metav1.ConditionUnknown
+			}
+			condition := nGlobal.Status.GetCondition(netGlo.NetworkGlobalDeletionBlocked)
+			if condition == nil {
+				return metav1.ConditionUnknown
+			}
+			return condition.Status
+		}
+	}
+
+	It("waits for its subnets to be deleted", func() {
+		nGlobal := createNetworkGlobal("ng-block", "10.40.0.0/16")
+		subnet := createSubnet("ng-block", "ng-block", "", "10.40.0.0/24")
+		Eventually(subnetState("ng-block"), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+		Eventually(func() []string {
+			_ = k8sClient.Get(ctx, client.ObjectKey{Name: nGlobal.Name, Namespace: testNamespace}, nGlobal)
+			return nGlobal.Finalizers
+		}, timeout, interval).ShouldNot(BeEmpty())
+
+		Expect(k8sClient.Delete(ctx, nGlobal)).To(Succeed())
+		Eventually(deletionBlocked("ng-block"), timeout, interval).Should(Equal(metav1.ConditionTrue))
+		Consistently(networkGlobalExists("ng-block"), time.Second, interval).Should(BeTrue())
+		Expect(subnetExists("ng-block")()).To(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, subnet)).To(Succeed())
+		Eventually(subnetExists("ng-block"), timeout, interval).Should(BeFalse())
+		Eventually(networkGlobalExists("ng-block"), timeout, interval).Should(BeFalse())
+	})
+
+	It("deletes its subnets first with the Cascade policy", func() {
+		nGlobal := createNetworkGlobal("ng-cascade", "10.41.0.0/16")
+		patch := client.MergeFrom(nGlobal.DeepCopy())
+		nGlobal.Spec.DeletionPolicy = netGlo.DeletionPolicyCascade
+		Expect(k8sClient.Patch(ctx, nGlobal, patch)).To(Succeed())
+		createSubnet("ng-cascade", "ng-cascade", "", "10.41.0.0/20")
+		createSubnet("ng-cascade-child", "ng-cascade", "ng-cascade", "10.41.1.0/24")
+		Eventually(subnetState("ng-cascade-child"), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+
+		Expect(k8sClient.Delete(ctx, nGlobal)).To(Succeed())
+		Eventually(subnetExists("ng-cascade-child"), timeout, interval).Should(BeFalse())
+		Eventually(subnetExists("ng-cascade"), timeout, interval).Should(BeFalse())
+		Eventually(networkGlobalExists("ng-cascade"), timeout, interval).Should(BeFalse())
+	})
+})
+
+var _ = Describe("Subnet deletion", func() {
+	ctx := context.Background()
+
+	deletionBlocked := func(name string) func() string {
+		return func() string {
+			condition := getSubnet(name).Status.GetCondition(corev1.SubnetDeletionBlocked)
+			if condition == nil || condition.Status != metav1.ConditionTrue {
+				return ""
+			}
+			return condition.Message
+		}
+	}
+
+	BeforeEach(func() {
+		nGlobal := &netGlo.NetworkGlobal{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Name: "subnet-deletion", Namespace: testNamespace}, nGlobal); err != nil {
+			createNetworkGlobal("subnet-deletion", "10.42.0.0/16")
+		}
+	})
+
+	It("waits for its child subnets to be deleted", func() {
+		parent := createSubnet("del-parent", "subnet-deletion", "", "10.42.0.0/20")
+		child := createSubnet("del-child", "subnet-deletion", "del-parent", "10.42.1.0/24")
+		Eventually(subnetState("del-child"), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+
+		Expect(k8sClient.Delete(ctx, parent)).To(Succeed())
+		Eventually(deletionBlocked("del-parent"), timeout, interval).Should(ContainSubstring("child subnets del-child"))
+		Consistently(subnetExists("del-parent"), time.Second, interval).Should(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, child)).To(Succeed())
+		Eventually(subnetExists("del-child"), timeout, interval).Should(BeFalse())
+		Eventually(subnetExists("del-parent"), timeout, interval).Should(BeFalse())
+	})
+
+	It("waits for the claims bound to it to be deleted", func() {
+		subnet := createSubnet("del-claims", "subnet-deletion", "", "10.42.16.0/24")
+		Eventually(subnetState("del-claims"), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+		claim := &corev1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "del-claim", Namespace: testNamespace},
+			Spec:       corev1.IPAddressClaimSpec{SubnetID: "del-claims"},
+		}
+		Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+		Eventually(func() corev1.IPAddressClaimState {
+			_ = k8sClient.Get(ctx, client.ObjectKey{Name: claim.Name, Namespace: testNamespace}, claim)
+			return claim.Status.State
+		}, timeout, interval).Should(Equal(corev1.IPAddressClaimStateBound))
+
+		Expect(k8sClient.Delete(ctx, subnet)).To(Succeed())
+		Eventually(deletionBlocked("del-claims"), timeout, interval).Should(ContainSubstring("address claims del-claim"))
+		Consistently(subnetExists("del-claims"), time.Second, interval).Should(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, claim)).To(Succeed())
+		Eventually(subnetExists("del-claims"), timeout, interval).Should(BeFalse())
+	})
+})
@@ -0,0 +1,191 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// DualStackSubnetReconciler keeps a Subnet for each address family of a
+// DualStackSubnet and reports their state and capacity per family.
+type DualStackSubnetReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=dualstacksubnets,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=dualstacksubnets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets,verbs=get;list;watch;create;update;patch;delete
+
+func (r *DualStackSubnetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("dualstacksubnet", req.NamespacedName)
+
+	dualStack := &corev1.DualStackSubnet{}
+	if err := r.Get(ctx, req.NamespacedName, dualStack); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// The Subnets are owned by the DualStackSubnet and go away with it.
+	if !dualStack.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	status := corev1.DualStackSubnetStatus{
+		State:              corev1.SubnetStateValid,
+		ObservedGeneration: dualStack.Generation,
+	}
+	var problem *subnetProblem
+	for _, family := range corev1.DualStackSubnetFamilies {
+		familyStatus, familyProblem, err := r.syncFamily(ctx, dualStack, family)
+		if err != nil {
+			log.Error(err, "Couldn't sync the subnet", "DualStackSubnet", dualStack.Name, "Family", family)
+			return ctrl.Result{}, err
+		}
+		*status.Family(family) = familyStatus
+		problem = worseProblem(problem, familyProblem)
+	}
+	if problem != nil {
+		status.State, status.Reason, status.Message = problem.state, problem.reason, problem.message
+	}
+
+	if equality.Semantic.DeepEqual(status, dualStack.Status) {
+		return ctrl.Result{}, nil
+	}
+	dualStack.Status = status
+	if err := r.Status().Update(ctx, dualStack); err != nil {
+		log.Error(err, "Couldn't update the status", "DualStackSubnet", dualStack.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// worseProblem returns the problem that decides the state of the segment. An
+// invalid family makes the whole segment invalid, a pending one only keeps an
+// otherwise valid segment pending. The first family wins a tie.
+func worseProblem(problem, familyProblem *subnetProblem) *subnetProblem {
+	if familyProblem != nil && (problem == nil || familyProblem.state == corev1.SubnetStateInvalid && problem.state != corev1.SubnetStateInvalid) {
+		return familyProblem
+	}
+	return problem
+}
+
+// syncFamily creates or updates the Subnet of the address family and returns
+// the state of the family. The problem is nil if the Subnet is valid.
+func (r *DualStackSubnetReconciler) syncFamily(ctx context.Context, dualStack *corev1.DualStackSubnet, family string) (corev1.DualStackFamilyStatus, *subnetProblem, error) {
+	name := corev1.DualStackSubnetMemberName(dualStack.Name, family)
+	desired := dualStack.Spec.SubnetSpec(family)
+	status := corev1.DualStackFamilyStatus{Subnet: name}
+
+	subnet := &corev1.Subnet{}
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: dualStack.Namespace}, subnet)
+	switch {
+	case apierrors.IsNotFound(err):
+		subnet = &corev1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: dualStack.Namespace,
+				Labels:    map[string]string{corev1.DualStackSubnetLabel: dualStack.Name},
+			},
+			Spec: desired,
+		}
+		if err := controllerutil.SetControllerReference(dualStack, subnet, r.Scheme); err != nil {
+			return status, nil, err
+		}
+		if err := r.Create(ctx, subnet); err != nil {
+			return rejectedFamily(status, family, err)
+		}
+		r.Log.Info("Created the subnet", "DualStackSubnet", dualStack.Name, "Subnet", name)
+		return familyStatus(status, family, subnet)
+	case err != nil:
+		return status, nil, err
+	case !metav1.IsControlledBy(subnet, dualStack):
+		status.State = corev1.SubnetStateInvalid
+		return status, invalidSubnet("SubnetNameTaken", "%s: subnet %q belongs to someone else", family, name), nil
+	}
+
+	// A carved CIDR is kept, it is only set by the Subnet controller.
+	if desired.CIDR == "" {
+		desired.CIDR, desired.Type = subnet.Spec.CIDR, subnet.Spec.Type
+	}
+	desired.ID = subnet.Spec.ID
+	if !equality.Semantic.DeepEqual(desired, subnet.Spec) {
+		patch := client.MergeFrom(subnet.DeepCopy())
+		subnet.Spec = desired
+		if err := r.Patch(ctx, subnet, patch); err != nil {
+			return rejectedFamily(status, family, err)
+		}
+		r.Log.Info("Updated the subnet", "DualStackSubnet", dualStack.Name, "Subnet", name)
+	}
+	return familyStatus(status, family, subnet)
+}
+
+// familyStatus copies the state and the capacity of the Subnet into the
+// status of its family.
+func familyStatus(status corev1.DualStackFamilyStatus, family string, subnet *corev1.Subnet) (corev1.DualStackFamilyStatus, *subnetProblem, error) {
+	status.CIDR = subnet.Spec.CIDR
+	status.State = subnet.Status.State
+	status.Gateway = subnet.Status.Gateway
+	status.Capacity = subnet.Status.Capacity
+	status.CapacityLeft = subnet.Status.CapacityLeft
+	status.DisplayCapacity = subnet.Status.DisplayCapacity
+	status.DisplayCapacityLeft = subnet.Status.DisplayCapacityLeft
+
+	switch status.State {
+	case corev1.SubnetStateValid:
+		return status, nil, nil
+	case "":
+		status.State = corev1.SubnetStatePending
+		return status, pendingSubnet("SubnetPending", "%s: subnet %q hasn't been checked yet", family, subnet.Name), nil
+	}
+	return status, &subnetProblem{
+		state:   status.State,
+		reason:  subnet.Status.Reason,
+		message: fmt.Sprintf("%s: %s", family, subnet.Status.Message),
+	}, nil
+}
+
+// rejectedFamily marks the family invalid if the Subnet webhook rejected its
+// Subnet and returns all other errors.
+func rejectedFamily(status corev1.DualStackFamilyStatus, family string, err error) (corev1.DualStackFamilyStatus, *subnetProblem, error) {
+	if !apierrors.IsForbidden(err) && !apierrors.IsInvalid(err) {
+		return status, nil, err
+	}
+	status.State = corev1.SubnetStateInvalid
+	return status, invalidSubnet("SubnetRejected", "%s: %v", family, err), nil
+}
+
+func (r *DualStackSubnetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.DualStackSubnet{}).
+		WithOptions(options).
+		Owns(&corev1.Subnet{}).
+		Complete(r)
+}
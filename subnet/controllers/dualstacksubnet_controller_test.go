@@ -0,0 +1,219 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+var _ = Describe("DualStackSubnet controller", func() {
+	ctx := context.Background()
+
+	createDualStackSubnet := func(name string, ipv4, ipv6 corev1.DualStackFamilySpec) *corev1.DualStackSubnet {
+		dualStack := &corev1.DualStackSubnet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: corev1.DualStackSubnetSpec{
+				NetworkGlobalID: "dualstack",
+				IPv4:            ipv4,
+				IPv6:            ipv6,
+			},
+		}
+		Expect(k8sClient.Create(ctx, dualStack)).To(Succeed())
+		return dualStack
+	}
+	dualStackStatus := func(name string) func() corev1.DualStackSubnetStatus {
+		return func() corev1.DualStackSubnetStatus {
+			dualStack := &corev1.DualStackSubnet{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: testNamespace}, dualStack); err != nil {
+				return corev1.DualStackSubnetStatus{}
+			}
+			return dualStack.Status
+		}
+	}
+	dualStackState := func(name string) func() corev1.SubnetState {
+		return func() corev1.SubnetState { return dualStackStatus(name)().State }
+	}
+	dualStackReason := func(name string) func() string {
+		return func() string { return dualStackStatus(name)().Reason }
+	}
+
+	BeforeEach(func() {
+		if err := k8sClient.Get(ctx, client.ObjectKey{Name: "dualstack", Namespace: testNamespace}, &netGlo.NetworkGlobal{}); err != nil {
+			createNetworkGlobal("dualstack", "10.50.0.0/16", "fd00:50::/48")
+		}
+	})
+
+	It("creates a Subnet for each family and reports them", func() {
+		dualStack := createDualStackSubnet("dss-valid",
+			corev1.DualStackFamilySpec{CIDR: "10.50.0.0/24"},
+			corev1.DualStackFamilySpec{CIDR: "fd00:50::/64"})
+		Eventually(dualStackState("dss-valid"), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+
+		status := dualStackStatus("dss-valid")()
+		Expect(status.IPv4.Subnet).To(Equal("dss-valid-ipv4"))
+		Expect(status.IPv4.CIDR).To(Equal("10.50.0.0/24"))
+		Expect(status.IPv4.State).To(Equal(corev1.SubnetStateValid))
+		Expect(status.IPv6.Subnet).To(Equal("dss-valid-ipv6"))
+		Expect(status.IPv6.CIDR).To(Equal("fd00:50::/64"))
+		Expect(status.IPv6.State).To(Equal(corev1.SubnetStateValid))
+
+		for _, name := range []string{"dss-valid-ipv4", "dss-valid-ipv6"} {
+			member := getSubnet(name)
+			Expect(member.Labels).To(HaveKeyWithValue(corev1.DualStackSubnetLabel, "dss-valid"))
+			Expect(member.Spec.NetworkGlobalID).To(Equal("dualstack"))
+			Expect(metav1.IsControlledBy(member, dualStack)).To(BeTrue())
+		}
+	})
+
+	It("keeps the segment pending while a family is pending", func() {
+		createDualStackSubnet("dss-pending",
+			corev1.DualStackFamilySpec{CIDR: "10.50.1.0/24"},
+			corev1.DualStackFamilySpec{SubnetParentID: "dss-missing-parent", PrefixLength: 64})
+		Eventually(dualStackReason("dss-pending"), timeout, interval).Should(Equal(corev1.SubnetReasonParentNotFound))
+
+		status := dualStackStatus("dss-pending")()
+		Expect(status.State).To(Equal(corev1.SubnetStatePending))
+		Expect(status.Message).To(HavePrefix("IPv6: "))
+		Expect(status.IPv4.State).To(Equal(corev1.SubnetStateValid))
+		Expect(status.IPv6.State).To(Equal(corev1.SubnetStatePending))
+	})
+
+	It("makes the segment invalid if a family is invalid, even if the other is pending", func() {
+		createDualStackSubnet("dss-invalid",
+			corev1.DualStackFamilySpec{CIDR: "10.99.0.0/24"},
+			corev1.DualStackFamilySpec{SubnetParentID: "dss-missing-parent", PrefixLength: 64})
+		Eventually(dualStackReason("dss-invalid"), timeout, interval).Should(Equal(corev1.SubnetReasonOutsideAddressSpace))
+
+		status := dualStackStatus("dss-invalid")()
+		Expect(status.State).To(Equal(corev1.SubnetStateInvalid))
+		Expect(status.Message).To(HavePrefix("IPv4: "))
+		Expect(status.IPv6.State).To(Equal(corev1.SubnetStatePending))
+	})
+
+	It("leaves a Subnet that already has the name of a member alone", func() {
+		createSubnet("dss-taken-ipv4", "dualstack", "", "10.50.2.0/24")
+		Eventually(subnetState("dss-taken-ipv4"), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+
+		createDualStackSubnet("dss-taken",
+			corev1.DualStackFamilySpec{CIDR: "10.50.3.0/24"},
+			corev1.DualStackFamilySpec{CIDR: "fd00:50:0:3::/64"})
+		Eventually(dualStackReason("dss-taken"), timeout, interval).Should(Equal("SubnetNameTaken"))
+
+		status := dualStackStatus("dss-taken")()
+		Expect(status.State).To(Equal(corev1.SubnetStateInvalid))
+		Expect(status.IPv4.State).To(Equal(corev1.SubnetStateInvalid))
+		Expect(status.IPv6.State).To(Equal(corev1.SubnetStateValid))
+
+		Consistently(func() string { return getSubnet("dss-taken-ipv4").Spec.CIDR }, time.Second, interval).Should(Equal("10.50.2.0/24"))
+		Expect(getSubnet("dss-taken-ipv4").Labels).NotTo(HaveKey(corev1.DualStackSubnetLabel))
+	})
+})
+
+var _ = Describe("DualStackSubnet family state", func() {
+	pending := pendingSubnet("Pending", "pending")
+	otherPending := pendingSubnet("OtherPending", "pending")
+	invalid := invalidSubnet("Invalid", "invalid")
+	otherInvalid := invalidSubnet("OtherInvalid", "invalid")
+
+	DescribeTable("combines the problems of the families",
+		func(problem, familyProblem, expected *subnetProblem) {
+			Expect(worseProblem(problem, familyProblem)).To(BeIdenticalTo(expected))
+		},
+		Entry("both valid", nil, nil, nil),
+		Entry("first pending", pending, nil, pending),
+		Entry("second pending", nil, pending, pending),
+		Entry("both pending", pending, otherPending, pending),
+		Entry("pending then invalid", pending, invalid, invalid),
+		Entry("invalid then pending", invalid, pending, invalid),
+		Entry("both invalid", invalid, otherInvalid, invalid),
+	)
+
+	DescribeTable("maps the errors of creating or updating a member",
+		func(err error, state corev1.SubnetState, reason string, returned bool) {
+			status, problem, returnedErr := rejectedFamily(corev1.DualStackFamilyStatus{Subnet: "rejected-ipv4"}, corev1.SubnetTypeIPv4, err)
+			Expect(status.Subnet).To(Equal("rejected-ipv4"))
+			Expect(status.State).To(Equal(state))
+			if returned {
+				Expect(returnedErr).To(Equal(err))
+				Expect(problem).To(BeNil())
+				return
+			}
+			Expect(returnedErr).NotTo(HaveOccurred())
+			Expect(problem.state).To(Equal(corev1.SubnetStateInvalid))
+			Expect(problem.reason).To(Equal(reason))
+			Expect(problem.message).To(HavePrefix("IPv4: "))
+		},
+		Entry("denied by the webhook",
+			apierrors.NewForbidden(schema.GroupResource{Resource: "subnets"}, "rejected-ipv4", errors.New("denied")),
+			corev1.SubnetStateInvalid, "SubnetRejected", false),
+		Entry("invalid",
+			apierrors.NewInvalid(corev1.GroupVersion.WithKind("Subnet").GroupKind(), "rejected-ipv4",
+				field.ErrorList{field.Invalid(field.NewPath("spec", "cidr"), "10.0.0.1/24", "must be written as 10.0.0.0/24")}),
+			corev1.SubnetStateInvalid, "SubnetRejected", false),
+		Entry("a conflict to retry",
+			apierrors.NewConflict(schema.GroupResource{Resource: "subnets"}, "rejected-ipv4", errors.New("changed")),
+			corev1.SubnetState(""), "", true),
+		Entry("an unreachable API server",
+			errors.New("connection refused"),
+			corev1.SubnetState(""), "", true),
+	)
+
+	It("reports a member that hasn't been checked yet as pending", func() {
+		member := &corev1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "unchecked-ipv6"},
+			Spec:       corev1.SubnetSpec{CIDR: "fd00::/64"},
+		}
+		status, problem, err := familyStatus(corev1.DualStackFamilyStatus{Subnet: member.Name}, corev1.SubnetTypeIPv6, member)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.CIDR).To(Equal("fd00::/64"))
+		Expect(status.State).To(Equal(corev1.SubnetStatePending))
+		Expect(problem.state).To(Equal(corev1.SubnetStatePending))
+		Expect(problem.reason).To(Equal("SubnetPending"))
+	})
+
+	It("passes the reason of an invalid member on", func() {
+		member := &corev1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "overlapping-ipv4"},
+			Spec:       corev1.SubnetSpec{CIDR: "10.0.0.0/24"},
+			Status: corev1.SubnetStatus{
+				State:   corev1.SubnetStateInvalid,
+				Reason:  corev1.SubnetReasonOverlap,
+				Message: "overlaps subnet \"other\"",
+			},
+		}
+		status, problem, err := familyStatus(corev1.DualStackFamilyStatus{Subnet: member.Name}, corev1.SubnetTypeIPv4, member)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.State).To(Equal(corev1.SubnetStateInvalid))
+		Expect(problem.reason).To(Equal(corev1.SubnetReasonOverlap))
+		Expect(problem.message).To(Equal("IPv4: overlaps subnet \"other\""))
+	})
+})
@@ -0,0 +1,49 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// recordStatusEvents emits events for the transitions between the old and the
+// new status of the Subnet, so that they show up in kubectl describe.
+func (r *SubnetReconciler) recordStatusEvents(subnet *corev1.Subnet, old, updated *corev1.SubnetStatus) {
+	if old.State != updated.State || old.Reason != updated.Reason {
+		if updated.State == corev1.SubnetStateValid {
+			r.Recorder.Eventf(subnet, corev1api.EventTypeNormal, "Valid", "%s fits into the address plan", subnet.Spec.CIDR)
+		} else {
+			r.Recorder.Event(subnet, corev1api.EventTypeWarning, updated.Reason, updated.Message)
+		}
+	}
+
+	if isConditionTrue(updated, corev1.SubnetExhausted) && !isConditionTrue(old, corev1.SubnetExhausted) {
+		r.Recorder.Event(subnet, corev1api.EventTypeWarning, "Exhausted", updated.GetCondition(corev1.SubnetExhausted).Message)
+	}
+
+	if isConditionTrue(updated, corev1.SubnetGatewayConflict) && !isConditionTrue(old, corev1.SubnetGatewayConflict) {
+		r.Recorder.Event(subnet, corev1api.EventTypeWarning, "GatewayConflict", updated.GetCondition(corev1.SubnetGatewayConflict).Message)
+	}
+}
+
+func isConditionTrue(status *corev1.SubnetStatus, t corev1.SubnetConditionType) bool {
+	condition := status.GetCondition(t)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
@@ -0,0 +1,264 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+// checkSubnetHierarchy checks that the Subnet lies within its parent, clear of
+// its reserved ranges, or within the address spaces of its NetworkGlobal if it
+// has no parent, and doesn't overlap an older sibling of the same NetworkGlobal.
+func (r *SubnetReconciler) checkSubnetHierarchy(ctx context.Context, subnet *corev1.Subnet) (*subnetProblem, error) {
+	var parent *corev1.Subnet
+	if parentName := subnet.Spec.SubnetParentID; parentName != "" {
+		parent = &corev1.Subnet{}
+		if err := r.Get(ctx, client.ObjectKey{Name: parentName, Namespace: subnet.Namespace}, parent); err != nil {
+			if apierrors.IsNotFound(err) {
+				return pendingSubnet(corev1.SubnetReasonParentNotFound,
+					"parent subnet %q doesn't exist", parentName), nil
+			}
+			return nil, err
+		}
+		if parent.Spec.NetworkGlobalID != subnet.Spec.NetworkGlobalID {
+			return invalidSubnet(corev1.SubnetReasonNetworkGlobalMismatch,
+				"parent subnet %q belongs to networkGlobal %q", parentName, parent.Spec.NetworkGlobalID), nil
+		}
+	}
+
+	// A Subnet still waiting to be carved has no CIDR to check yet.
+	if needsCarving(subnet) {
+		if parent.Status.State != corev1.SubnetStateValid {
+			return pendingSubnet(corev1.SubnetReasonParentNotValid,
+				"waiting for parent subnet %q to become valid", parent.Name), nil
+		}
+		// The webhook can't check the prefix length against a parent that was
+		// carved after the Subnet was created.
+		parentNet, err := ipam.ParseCIDR(parent.Spec.CIDR)
+		if err != nil {
+			return invalidSubnet(corev1.SubnetReasonInvalidCIDR, "parent subnet %q: %v", parent.Name, err), nil
+		}
+		if t := ipam.Family(parentNet); subnet.Spec.Type != "" && subnet.Spec.Type != t {
+			return invalidSubnet(corev1.SubnetReasonOutsideParent,
+				"an %s block can't be carved out of the %s parent subnet %q", subnet.Spec.Type, t, parent.Name), nil
+		}
+		if ones, bits := parentNet.Mask.Size(); subnet.Spec.PrefixLength < ones || subnet.Spec.PrefixLength > bits {
+			return invalidSubnet(corev1.SubnetReasonOutsideParent,
+				"a /%d block doesn't fit into parent subnet %q (%s)", subnet.Spec.PrefixLength, parent.Name, parentNet), nil
+		}
+		return pendingSubnet(corev1.SubnetReasonParentExhausted,
+			"parent subnet %q has no free /%d block left", parent.Name, subnet.Spec.PrefixLength), nil
+	}
+
+	ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+	if err != nil {
+		return invalidSubnet(corev1.SubnetReasonInvalidCIDR, "%v", err), nil
+	}
+
+	if parent == nil && subnet.Spec.NetworkGlobalID != "" {
+		nGlobal, err := getNetworkGlobal(ctx, r, subnet.Namespace, subnet.Spec.NetworkGlobalID)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return pendingSubnet(corev1.SubnetReasonNetworkGlobalNotFound,
+					"networkGlobal %q doesn't exist", subnet.Spec.NetworkGlobalID), nil
+			}
+			return nil, err
+		}
+		if spaces := nGlobal.Spec.AddressSpaces; len(spaces) > 0 && !ipam.ContainedInAny(spaces, ipNet) {
+			return invalidSubnet(corev1.SubnetReasonOutsideAddressSpace,
+				"%s is not within an address space of networkGlobal %q %v", ipNet, nGlobal.Name, spaces), nil
+		}
+	}
+
+	if parent != nil {
+		parentNet, err := ipam.ParseCIDR(parent.Spec.CIDR)
+		if err != nil {
+			return invalidSubnet(corev1.SubnetReasonInvalidCIDR,
+				"parent subnet %q has an invalid CIDR", parent.Name), nil
+		}
+		if !ipam.Contains(parentNet, ipNet) {
+			return invalidSubnet(corev1.SubnetReasonOutsideParent,
+				"%s is not within parent subnet %q (%s)", ipNet, parent.Name, parentNet), nil
+		}
+		for _, reserved := range parent.Spec.ExcludedRanges() {
+			first, last, err := ipam.ParseRange(reserved.Start, reserved.End)
+			if err == nil && ipam.OverlapsRange(ipNet, first, last) {
+				return invalidSubnet(corev1.SubnetReasonParentReservedRange,
+					"%s overlaps the reserved range %s of parent subnet %q", ipNet, reserved, parent.Name), nil
+			}
+		}
+	}
+
+	siblings, err := siblingSubnets(ctx, r, subnet)
+	if err != nil {
+		return nil, err
+	}
+	for i := range siblings {
+		sibling := &siblings[i]
+		siblingNet, err := ipam.ParseCIDR(sibling.Spec.CIDR)
+		if err != nil {
+			continue
+		}
+		if ipam.Overlaps(ipNet, siblingNet) && isOlderSubnet(sibling, subnet) {
+			return invalidSubnet(corev1.SubnetReasonOverlap,
+				"%s overlaps subnet %q (%s)", ipNet, sibling.Name, siblingNet), nil
+		}
+	}
+	return nil, nil
+}
+
+// childSubnets returns the Subnets whose SubnetParentID points at the Subnet.
+func childSubnets(ctx context.Context, c client.Reader, subnet *corev1.Subnet) ([]corev1.Subnet, error) {
+	return listSubnets(ctx, c, subnet.Namespace, func(s *corev1.Subnet) bool {
+		return s.Spec.SubnetParentID == subnet.Name && s.Name != subnet.Name
+	})
+}
+
+// siblingSubnets returns the other Subnets sharing the parent and the
+// NetworkGlobal of the Subnet. It only works with the cached client.
+func siblingSubnets(ctx context.Context, c client.Reader, subnet *corev1.Subnet) ([]corev1.Subnet, error) {
+	sameNetwork, err := indexedSubnets(ctx, c, subnet.Namespace, corev1.NetworkGlobalIDField, subnet.Spec.NetworkGlobalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var siblings []corev1.Subnet
+	for _, s := range sameNetwork {
+		if s.Spec.SubnetParentID == subnet.Spec.SubnetParentID && s.Name != subnet.Name {
+			siblings = append(siblings, s)
+		}
+	}
+	return siblings, nil
+}
+
+// listSubnets returns the Subnets of the namespace matching the filter.
+func listSubnets(ctx context.Context, c client.Reader, namespace string, filter func(*corev1.Subnet) bool) ([]corev1.Subnet, error) {
+	subnets := &corev1.SubnetList{}
+	if err := c.List(ctx, subnets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var matches []corev1.Subnet
+	for i := range subnets.Items {
+		if filter(&subnets.Items[i]) {
+			matches = append(matches, subnets.Items[i])
+		}
+	}
+	return matches, nil
+}
+
+// relatedSubnetRequests maps a Subnet to requests for its parent, siblings and
+// children, whose capacity and validity depend on it.
+func (r *SubnetReconciler) relatedSubnetRequests(a handler.MapObject) []reconcile.Request {
+	ctx := context.Background()
+	subnet, ok := a.Object.(*corev1.Subnet)
+	if !ok {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	if subnet.Spec.SubnetParentID != "" {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+			Name:      subnet.Spec.SubnetParentID,
+			Namespace: subnet.Namespace,
+		}})
+	}
+
+	children, err := indexedSubnets(ctx, r, subnet.Namespace, corev1.SubnetParentIDField, subnet.Name)
+	if err != nil {
+		r.Log.Error(err, "Couldn't list the related subnets", "Subnet", subnet.Name)
+		return requests
+	}
+	siblings, err := siblingSubnets(ctx, r, subnet)
+	if err != nil {
+		r.Log.Error(err, "Couldn't list the related subnets", "Subnet", subnet.Name)
+		return requests
+	}
+	for _, s := range append(children, siblings...) {
+		if s.Name == subnet.Name {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+		}})
+	}
+	return requests
+}
+
+// isOlderSubnet reports whether a was created before b. Subnets created in
+// the same second are ordered by name so that exactly one of two overlapping
+// siblings keeps its block.
+func isOlderSubnet(a, b *corev1.Subnet) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// dependentSubnetRequests maps a NetworkGlobal to requests for the Subnets
+// referencing it, so they leave the Pending state once it exists.
+func (r *SubnetReconciler) dependentSubnetRequests(a handler.MapObject) []reconcile.Request {
+	nGlobal, ok := a.Object.(*netGlo.NetworkGlobal)
+	if !ok {
+		return nil
+	}
+
+	subnets, err := indexedSubnets(context.Background(), r, nGlobal.Namespace, corev1.NetworkGlobalIDField, nGlobal.Spec.ID)
+	if err != nil {
+		r.Log.Error(err, "Couldn't list the dependent subnets", "NetworkGlobal", nGlobal.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, s := range subnets {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+		}})
+	}
+	return requests
+}
+
+// claimSubnetRequests maps an IPAddressClaim to requests for its Subnets, whose
+// capacity depends on the addresses handed out.
+func claimSubnetRequests(a handler.MapObject) []reconcile.Request {
+	claim, ok := a.Object.(*corev1.IPAddressClaim)
+	if !ok {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, subnetName := range claimSubnetNames(claim) {
+		if subnetName != "" {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+				Name:      subnetName,
+				Namespace: claim.Namespace,
+			}})
+		}
+	}
+	return requests
+}
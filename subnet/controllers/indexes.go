@@ -0,0 +1,93 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+// SetupIndexes registers the cache field indexes on Subnets the controllers
+// and the webhooks resolve references with. The spec.id index on
+// NetworkGlobals is registered by the SetupIndexes of the networkGlobal
+// controllers.
+func SetupIndexes(mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	subnetFields := map[string]func(*corev1.Subnet) string{
+		corev1.NetworkGlobalIDField: func(s *corev1.Subnet) string { return s.Spec.NetworkGlobalID },
+		corev1.SubnetParentIDField:  func(s *corev1.Subnet) string { return s.Spec.SubnetParentID },
+		corev1.PartitionIDField:     func(s *corev1.Subnet) string { return s.Spec.PartitionID },
+	}
+	for field, value := range subnetFields {
+		value := value
+		if err := indexer.IndexField(&corev1.Subnet{}, field, func(o runtime.Object) []string {
+			return nonEmpty(value(o.(*corev1.Subnet)))
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// getNetworkGlobal returns the NetworkGlobal of the namespace with the given
+// spec.id. Should the ID be taken twice, the oldest NetworkGlobal wins.
+func getNetworkGlobal(ctx context.Context, c client.Reader, namespace, id string) (*netGlo.NetworkGlobal, error) {
+	nGlobals := &netGlo.NetworkGlobalList{}
+	if err := c.List(ctx, nGlobals, client.InNamespace(namespace), client.MatchingFields{netGlo.IDField: id}); err != nil {
+		return nil, err
+	}
+	if len(nGlobals.Items) == 0 {
+		return nil, apierrors.NewNotFound(netGlo.GroupVersion.WithResource("networkglobals").GroupResource(), id)
+	}
+
+	oldest := &nGlobals.Items[0]
+	for i := range nGlobals.Items[1:] {
+		nGlobal := &nGlobals.Items[i+1]
+		if nGlobal.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = nGlobal
+		}
+	}
+	return oldest, nil
+}
+
+// indexedSubnets returns the Subnets of the namespace whose indexed field has
+// the given value. It only works with the cached client.
+func indexedSubnets(ctx context.Context, c client.Reader, namespace, field, value string) ([]corev1.Subnet, error) {
+	if value == "" {
+		return nil, nil
+	}
+	subnets := &corev1.SubnetList{}
+	if err := c.List(ctx, subnets, client.InNamespace(namespace), client.MatchingFields{field: value}); err != nil {
+		return nil, err
+	}
+	return subnets.Items, nil
+}
@@ -0,0 +1,272 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// IPAddressClaimReconciler reconciles an IPAddressClaim object
+type IPAddressClaimReconciler struct {
+	client.Client
+	// APIReader reads around the cache, so that an address handed out a
+	// moment ago is never handed out twice.
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=ipaddressclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=ipaddressclaims/status,verbs=get;update;patch
+
+func (r *IPAddressClaimReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("ipaddressclaim", req.NamespacedName)
+
+	claim := &corev1.IPAddressClaim{}
+	if err := r.Get(ctx, req.NamespacedName, claim); err != nil {
+		log.Info("unable to fetch IPAddressClaim", "IPAddressClaim", req, "Error", err)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// A deleted claim gives its address back by disappearing, and a bound
+	// claim keeps its address for good.
+	if !claim.DeletionTimestamp.IsZero() || claim.Status.State == corev1.IPAddressClaimStateBound {
+		return ctrl.Result{}, nil
+	}
+
+	// Subnets are always locked in the same order, so that claims of a
+	// dual-stack subnet can't deadlock.
+	for _, subnetName := range claimSubnetNames(claim) {
+		unlock := allocationLocks.lock(types.NamespacedName{Name: subnetName, Namespace: claim.Namespace})
+		defer unlock()
+	}
+
+	status, err := r.bindClaim(ctx, claim)
+	if err != nil {
+		log.Error(err, "Couldn't look for a free address", "IPAddressClaim", claim.Name)
+		return ctrl.Result{}, err
+	}
+	if status == claim.Status {
+		return ctrl.Result{}, nil
+	}
+
+	clone := claim.DeepCopy()
+	clone.Status = status
+	if err := r.Status().Update(ctx, clone); err != nil {
+		log.Error(err, "Couldn't update the status", "IPAddressClaim", claim.Name)
+		return ctrl.Result{}, err
+	}
+	if status.State == corev1.IPAddressClaimStateBound {
+		log.Info("Bound the address", "IPAddressClaim", claim.Name, "Address", status.Address, "IPv6Address", status.IPv6Address)
+	}
+	return ctrl.Result{}, nil
+}
+
+// claimSubnetNames returns the names of the Subnets the claim takes its
+// addresses from.
+func claimSubnetNames(claim *corev1.IPAddressClaim) []string {
+	if claim.Spec.DualStackSubnetID == "" {
+		return []string{claim.Spec.SubnetID}
+	}
+	var names []string
+	for _, family := range corev1.DualStackSubnetFamilies {
+		names = append(names, corev1.DualStackSubnetMemberName(claim.Spec.DualStackSubnetID, family))
+	}
+	return names
+}
+
+// bindClaim picks the addresses for the claim. If there are none, the
+// returned status says why.
+func (r *IPAddressClaimReconciler) bindClaim(ctx context.Context, claim *corev1.IPAddressClaim) (corev1.IPAddressClaimStatus, error) {
+	switch {
+	case claim.Spec.DualStackSubnetID != "":
+		return r.bindDualStackClaim(ctx, claim)
+	case claim.Spec.SubnetID == "":
+		return claimStatus(corev1.IPAddressClaimStateInvalid, corev1.IPAddressClaimReasonNoSubnet,
+			"either subnetID or dualStackSubnetID has to be set"), nil
+	}
+	return r.bindAddress(ctx, claim.Namespace, claim.Spec.SubnetID, claim.Spec.Address)
+}
+
+// bindDualStackClaim picks one address of each family from the Subnets of the
+// DualStackSubnet. The claim is only bound once both are found.
+func (r *IPAddressClaimReconciler) bindDualStackClaim(ctx context.Context, claim *corev1.IPAddressClaim) (corev1.IPAddressClaimStatus, error) {
+	dualStack := &corev1.DualStackSubnet{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: claim.Spec.DualStackSubnetID, Namespace: claim.Namespace}, dualStack); err != nil {
+		if apierrors.IsNotFound(err) {
+			return claimStatus(corev1.IPAddressClaimStatePending, corev1.IPAddressClaimReasonSubnetNotFound,
+				"dual-stack subnet %q doesn't exist", claim.Spec.DualStackSubnetID), nil
+		}
+		return corev1.IPAddressClaimStatus{}, err
+	}
+
+	bound := corev1.IPAddressClaimStatus{State: corev1.IPAddressClaimStateBound}
+	for _, family := range corev1.DualStackSubnetFamilies {
+		var requested string
+		if ip := net.ParseIP(claim.Spec.Address); ip != nil && (ip.To4() != nil) == (family == corev1.SubnetTypeIPv4) {
+			requested = claim.Spec.Address
+		}
+		status, err := r.bindAddress(ctx, claim.Namespace, corev1.DualStackSubnetMemberName(dualStack.Name, family), requested)
+		if err != nil || status.State != corev1.IPAddressClaimStateBound {
+			return status, err
+		}
+		if family == corev1.SubnetTypeIPv6 {
+			bound.IPv6Address = status.Address
+		} else {
+			bound.Address = status.Address
+		}
+	}
+	return bound, nil
+}
+
+// bindAddress picks the requested address, or any free one if requested is
+// empty, from the Subnet. If there is none, the returned status says why.
+func (r *IPAddressClaimReconciler) bindAddress(ctx context.Context, namespace, subnetName, requested string) (corev1.IPAddressClaimStatus, error) {
+	subnet := &corev1.Subnet{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: subnetName, Namespace: namespace}, subnet); err != nil {
+		if apierrors.IsNotFound(err) {
+			return claimStatus(corev1.IPAddressClaimStatePending, corev1.IPAddressClaimReasonSubnetNotFound,
+				"subnet %q doesn't exist", subnetName), nil
+		}
+		return corev1.IPAddressClaimStatus{}, err
+	}
+	if subnet.Status.State != corev1.SubnetStateValid || !subnet.DeletionTimestamp.IsZero() {
+		return claimStatus(corev1.IPAddressClaimStatePending, corev1.IPAddressClaimReasonSubnetNotValid,
+			"subnet %q is not valid", subnet.Name), nil
+	}
+
+	free, err := freeAddresses(ctx, r.APIReader, subnet)
+	if err != nil {
+		return corev1.IPAddressClaimStatus{}, err
+	}
+
+	var ip net.IP
+	if requested != "" {
+		ipNet, _ := ipam.ParseCIDR(subnet.Spec.CIDR)
+		ip = net.ParseIP(requested)
+		if ip == nil || !ipam.Usable(ipNet).Has(ip) {
+			return claimStatus(corev1.IPAddressClaimStateInvalid, corev1.IPAddressClaimReasonAddressOutOfSubnet,
+				"%s is not a usable address of subnet %q (%s)", requested, subnet.Name, ipNet), nil
+		}
+		if usable, _ := usableAddresses(subnet); !usable.Has(ip) {
+			return claimStatus(corev1.IPAddressClaimStateInvalid, corev1.IPAddressClaimReasonAddressReserved,
+				"%s is reserved in subnet %q", ip, subnet.Name), nil
+		}
+		if !free.Has(ip) {
+			return claimStatus(corev1.IPAddressClaimStatePending, corev1.IPAddressClaimReasonAddressInUse,
+				"%s is taken by another claim or a child subnet", ip), nil
+		}
+	} else if ip = free.First(); ip == nil {
+		return claimStatus(corev1.IPAddressClaimStatePending, corev1.IPAddressClaimReasonSubnetExhausted,
+			"subnet %q has no free address left", subnet.Name), nil
+	}
+
+	return corev1.IPAddressClaimStatus{
+		Address: ip.String(),
+		State:   corev1.IPAddressClaimStateBound,
+	}, nil
+}
+
+func claimStatus(state corev1.IPAddressClaimState, reason, format string, args ...interface{}) corev1.IPAddressClaimStatus {
+	return corev1.IPAddressClaimStatus{State: state, Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// waitingClaimRequests maps a Subnet to requests for the claims still waiting
+// for an address from it.
+func (r *IPAddressClaimReconciler) waitingClaimRequests(a handler.MapObject) []reconcile.Request {
+	ctx := context.Background()
+	subnet, ok := a.Object.(*corev1.Subnet)
+	if !ok {
+		return nil
+	}
+
+	claims, err := subnetClaims(ctx, r, subnet)
+	if err != nil {
+		r.Log.Error(err, "Couldn't list the claims", "Subnet", subnet.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, claim := range claims {
+		if claim.Status.State == corev1.IPAddressClaimStateBound {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      claim.Name,
+			Namespace: claim.Namespace,
+		}})
+	}
+	return requests
+}
+
+func (r *IPAddressClaimReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.IPAddressClaim{}).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &corev1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.waitingClaimRequests),
+		}).
+		Complete(r)
+}
+
+// allocationLocks serializes everything handing out space of a Subnet, be it
+// addresses for IPAddressClaims or blocks for carved child Subnets. The locks
+// only hold within the process, so a manager running more than one replica
+// has to run with leader election, or two replicas could hand out the same
+// address to different claims.
+var allocationLocks subnetLocks
+
+// subnetLocks serializes the address allocations per Subnet.
+type subnetLocks struct {
+	mu    sync.Mutex
+	locks map[types.NamespacedName]*sync.Mutex
+}
+
+// lock locks the Subnet and returns the function unlocking it.
+func (l *subnetLocks) lock(key types.NamespacedName) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[types.NamespacedName]*sync.Mutex{}
+	}
+	m, ok := l.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[key] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
@@ -0,0 +1,137 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+const (
+	timeout  = 10 * time.Second
+	interval = 100 * time.Millisecond
+)
+
+var _ = Describe("IPAddressClaim controller", func() {
+	ctx := context.Background()
+	const namespace = "default"
+
+	claimStatus := func(name string) func() corev1.IPAddressClaimStatus {
+		return func() corev1.IPAddressClaimStatus {
+			claim := &corev1.IPAddressClaim{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, claim); err != nil {
+				return corev1.IPAddressClaimStatus{}
+			}
+			return claim.Status
+		}
+	}
+	claimAddress := func(name string) func() string {
+		return func() string { return claimStatus(name)().Address }
+	}
+	claimState := func(name string) func() corev1.IPAddressClaimState {
+		return func() corev1.IPAddressClaimState { return claimStatus(name)().State }
+	}
+	claimReason := func(name string) func() string {
+		return func() string { return claimStatus(name)().Reason }
+	}
+	createOnce := func(obj runtime.Object) {
+		if err := k8sClient.Create(ctx, obj); !apierrors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+	newClaim := func(name, address string) *corev1.IPAddressClaim {
+		return &corev1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.IPAddressClaimSpec{SubnetID: "claims", Address: address},
+		}
+	}
+
+	BeforeEach(func() {
+		nGlobal := &netGlo.NetworkGlobal{
+			ObjectMeta: metav1.ObjectMeta{Name: "claims", Namespace: namespace},
+			Spec:       netGlo.NetworkGlobalSpec{ID: "claims", AddressSpaces: []string{"10.0.0.0/8"}},
+		}
+		createOnce(nGlobal)
+
+		subnet := &corev1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "claims", Namespace: namespace},
+			Spec: corev1.SubnetSpec{
+				Type:            corev1.SubnetTypeIPv4,
+				CIDR:            "10.12.34.0/29",
+				NetworkGlobalID: "claims",
+			},
+		}
+		createOnce(subnet)
+		Eventually(func() corev1.SubnetState {
+			_ = k8sClient.Get(ctx, client.ObjectKey{Name: "claims", Namespace: namespace}, subnet)
+			return subnet.Status.State
+		}, timeout, interval).Should(Equal(corev1.SubnetStateValid))
+	})
+
+	It("binds the lowest free address past the gateway and releases it on deletion", func() {
+		first := newClaim("first", "")
+		Expect(k8sClient.Create(ctx, first)).To(Succeed())
+		Eventually(claimState("first"), timeout, interval).Should(Equal(corev1.IPAddressClaimStateBound))
+		Expect(claimAddress("first")()).To(Equal("10.12.34.2"))
+
+		second := newClaim("second", "")
+		Expect(k8sClient.Create(ctx, second)).To(Succeed())
+		Eventually(claimAddress("second"), timeout, interval).Should(Equal("10.12.34.3"))
+
+		By("releasing the address of a deleted claim")
+		Expect(k8sClient.Delete(ctx, first)).To(Succeed())
+		third := newClaim("third", "10.12.34.2")
+		Expect(k8sClient.Create(ctx, third)).To(Succeed())
+		Eventually(claimState("third"), timeout, interval).Should(Equal(corev1.IPAddressClaimStateBound))
+		Expect(claimAddress("third")()).To(Equal("10.12.34.2"))
+
+		Expect(k8sClient.Delete(ctx, second)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, third)).To(Succeed())
+	})
+
+	It("keeps requested addresses that are taken or reserved pending or invalid", func() {
+		taken := newClaim("taken", "10.12.34.4")
+		Expect(k8sClient.Create(ctx, taken)).To(Succeed())
+		Eventually(claimState("taken"), timeout, interval).Should(Equal(corev1.IPAddressClaimStateBound))
+
+		again := newClaim("again", "10.12.34.4")
+		Expect(k8sClient.Create(ctx, again)).To(Succeed())
+		Eventually(claimReason("again"), timeout, interval).Should(Equal(corev1.IPAddressClaimReasonAddressInUse))
+
+		gateway := newClaim("gateway", "10.12.34.1")
+		Expect(k8sClient.Create(ctx, gateway)).To(Succeed())
+		Eventually(claimReason("gateway"), timeout, interval).Should(Equal(corev1.IPAddressClaimReasonAddressReserved))
+
+		By("binding the pending claim once the address is released")
+		Expect(k8sClient.Delete(ctx, taken)).To(Succeed())
+		Eventually(claimState("again"), timeout, interval).Should(Equal(corev1.IPAddressClaimStateBound))
+
+		Expect(k8sClient.Delete(ctx, again)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, gateway)).To(Succeed())
+	})
+})
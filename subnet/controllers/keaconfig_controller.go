@@ -0,0 +1,209 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+
+	"github.com/go-logr/logr"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+	"gardener/subnet/kea"
+)
+
+// Keys of the Kea ConfigMap, to be included as the subnet4 and subnet6 lists
+// of the Dhcp4 and Dhcp6 configurations
+const (
+	KeaSubnet4Key = "subnet4.json"
+	KeaSubnet6Key = "subnet6.json"
+)
+
+// KeaConfigMapName returns the name of the ConfigMap holding the Kea DHCP
+// subnets of the Partition.
+func KeaConfigMapName(partition string) string {
+	return "kea-" + partition
+}
+
+// KeaConfigReconciler renders the Subnets of a Partition into the subnet lists
+// of the Kea DHCP servers and keeps them in a ConfigMap
+type KeaConfigReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=partitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=ipaddressclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+
+func (r *KeaConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("partition", req.NamespacedName)
+
+	partition := &corev1.Partition{}
+	if err := r.Get(ctx, req.NamespacedName, partition); err != nil {
+		// The ConfigMap of a deleted Partition is garbage collected.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	networks, err := r.partitionNetworks(ctx, partition)
+	if err != nil {
+		log.Error(err, "Couldn't collect the subnets", "Partition", partition.Name)
+		return ctrl.Result{}, err
+	}
+	subnet4, err := kea.Render(kea.Subnet4(networks))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	subnet6, err := kea.Render(kea.Subnet6(networks))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	configMap := &corev1api.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      KeaConfigMapName(partition.Name),
+		Namespace: partition.Namespace,
+	}}
+	result, err := controllerutil.CreateOrUpdate(ctx, r, configMap, func() error {
+		configMap.Data = map[string]string{KeaSubnet4Key: subnet4, KeaSubnet6Key: subnet6}
+		return controllerutil.SetControllerReference(partition, configMap, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Couldn't write the Kea configuration", "Partition", partition.Name, "ConfigMap", configMap.Name)
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Wrote the Kea configuration", "Partition", partition.Name, "ConfigMap", configMap.Name, "Operation", result)
+	}
+	return ctrl.Result{}, nil
+}
+
+// partitionNetworks returns the valid Subnets of the Partition that hosts are
+// attached to, that is those without children. Their DHCP pools, which the
+// address management keeps clear of claims, become the pools of Kea and their
+// bound claims with a hardware address reservations.
+func (r *KeaConfigReconciler) partitionNetworks(ctx context.Context, partition *corev1.Partition) ([]kea.Network, error) {
+	subnets, err := indexedSubnets(ctx, r, partition.Namespace, corev1.PartitionIDField, partition.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []kea.Network
+	for i := range subnets {
+		subnet := &subnets[i]
+		if subnet.Status.State != corev1.SubnetStateValid || !subnet.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// Only the innermost subnets are L2 segments the DHCP servers serve.
+		children, err := indexedSubnets(ctx, r, subnet.Namespace, corev1.SubnetParentIDField, subnet.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) > 0 {
+			continue
+		}
+		ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+		if err != nil {
+			continue
+		}
+
+		network := kea.Network{
+			Name:          subnet.Name,
+			CIDR:          ipNet,
+			Gateway:       net.ParseIP(subnet.Status.Gateway),
+			SearchDomains: subnet.Status.SearchDomains,
+			MTU:           subnet.Status.MTU,
+			VLANID:        subnet.Status.VLANID,
+		}
+		for _, pool := range subnet.Spec.DHCPPools {
+			if first, last, err := ipam.ParseRange(pool.Start, pool.End); err == nil {
+				network.Pools = append(network.Pools, ipam.Range{First: first, Last: last})
+			}
+		}
+		for _, server := range subnet.Status.DNSServers {
+			if ip := net.ParseIP(server); ip != nil {
+				network.DNSServers = append(network.DNSServers, ip)
+			}
+		}
+
+		claims, err := subnetClaims(ctx, r, subnet)
+		if err != nil {
+			return nil, err
+		}
+		for _, claim := range claims {
+			if claim.Status.State != corev1.IPAddressClaimStateBound || claim.Spec.HWAddress == "" {
+				continue
+			}
+			for _, address := range []string{claim.Status.Address, claim.Status.IPv6Address} {
+				if ip := net.ParseIP(address); ip != nil && ipNet.Contains(ip) {
+					network.Reservations = append(network.Reservations, kea.Reservation{HWAddress: claim.Spec.HWAddress, Address: ip})
+				}
+			}
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func (r *KeaConfigReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// The Partition controller reconciles Partitions as well, so this one
+	// needs a name of its own.
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("keaconfig").
+		For(&corev1.Partition{}).
+		Owns(&corev1api.ConfigMap{}).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &corev1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(subnetPartitionRequests),
+		}).
+		Watches(&source.Kind{Type: &corev1.IPAddressClaim{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.claimPartitionRequests),
+		}).
+		Complete(r)
+}
+
+// claimPartitionRequests maps an IPAddressClaim to requests for the Partitions
+// of its Subnets, whose reservations include it.
+func (r *KeaConfigReconciler) claimPartitionRequests(a handler.MapObject) []reconcile.Request {
+	claim, ok := a.Object.(*corev1.IPAddressClaim)
+	if !ok {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, name := range claimSubnetNames(claim) {
+		subnet := &corev1.Subnet{}
+		if err := r.Get(context.Background(), client.ObjectKey{Name: name, Namespace: claim.Namespace}, subnet); err != nil {
+			continue
+		}
+		requests = append(requests, subnetPartitionRequests(handler.MapObject{Meta: subnet, Object: subnet})...)
+	}
+	return requests
+}
@@ -0,0 +1,160 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	corev1 "gardener/subnet/api/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+var subnetLabels = []string{"namespace", "name", "network_global", "partition", "family"}
+
+var networkGlobalLabels = []string{"namespace", "name", "family"}
+
+var (
+	subnetCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "subnet_capacity_addresses",
+		Help: "Number of usable addresses of the subnet.",
+	}, subnetLabels)
+	subnetUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "subnet_used_addresses",
+		Help: "Number of addresses of the subnet taken by child subnets or address claims.",
+	}, subnetLabels)
+	subnetFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "subnet_free_addresses",
+		Help: "Number of addresses of the subnet that are still free.",
+	}, subnetLabels)
+	subnetUtilization = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "subnet_utilization_ratio",
+		Help: "Share of the usable addresses of the subnet that are taken, between 0 and 1.",
+	}, subnetLabels)
+
+	networkGlobalCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "networkglobal_capacity_addresses",
+		Help: "Number of addresses in the address spaces of the network, per address family.",
+	}, networkGlobalLabels)
+	networkGlobalUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "networkglobal_used_addresses",
+		Help: "Number of addresses of the network taken by valid top-level subnets, per address family.",
+	}, networkGlobalLabels)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		subnetCapacity, subnetUsed, subnetFree, subnetUtilization,
+		networkGlobalCapacity, networkGlobalUsed,
+	)
+}
+
+// subnetMetricLabels remembers the labels each Subnet was last reported with,
+// so that the old series go away when a label such as the partition changes.
+var subnetMetricLabels = struct {
+	sync.Mutex
+	labels map[types.NamespacedName]prometheus.Labels
+}{labels: map[types.NamespacedName]prometheus.Labels{}}
+
+// reportSubnetMetrics sets the utilization gauges of the Subnet.
+func reportSubnetMetrics(subnet *corev1.Subnet, capacity, free *big.Int) {
+	labels := prometheus.Labels{
+		"namespace":      subnet.Namespace,
+		"name":           subnet.Name,
+		"network_global": subnet.Spec.NetworkGlobalID,
+		"partition":      subnet.Spec.PartitionID,
+		"family":         subnetFamily(subnet),
+	}
+
+	key := types.NamespacedName{Name: subnet.Name, Namespace: subnet.Namespace}
+	subnetMetricLabels.Lock()
+	if old, ok := subnetMetricLabels.labels[key]; ok && !equalLabels(old, labels) {
+		deleteSubnetSeries(old)
+	}
+	subnetMetricLabels.labels[key] = labels
+	subnetMetricLabels.Unlock()
+
+	used := new(big.Int).Sub(capacity, free)
+	subnetCapacity.With(labels).Set(toFloat(capacity))
+	subnetUsed.With(labels).Set(toFloat(used))
+	subnetFree.With(labels).Set(toFloat(free))
+	ratio := 0.0
+	if capacity.Sign() > 0 {
+		ratio, _ = new(big.Rat).SetFrac(used, capacity).Float64()
+	}
+	subnetUtilization.With(labels).Set(ratio)
+}
+
+// forgetSubnetMetrics drops the series of a Subnet that is gone.
+func forgetSubnetMetrics(key types.NamespacedName) {
+	subnetMetricLabels.Lock()
+	defer subnetMetricLabels.Unlock()
+	if labels, ok := subnetMetricLabels.labels[key]; ok {
+		deleteSubnetSeries(labels)
+		delete(subnetMetricLabels.labels, key)
+	}
+}
+
+func deleteSubnetSeries(labels prometheus.Labels) {
+	subnetCapacity.Delete(labels)
+	subnetUsed.Delete(labels)
+	subnetFree.Delete(labels)
+	subnetUtilization.Delete(labels)
+}
+
+// reportNetworkGlobalMetrics sets the totals of an address family of the NetworkGlobal.
+func reportNetworkGlobalMetrics(nGlobal *netGlo.NetworkGlobal, family string, capacity, used *big.Int) {
+	labels := prometheus.Labels{"namespace": nGlobal.Namespace, "name": nGlobal.Name, "family": family}
+	networkGlobalCapacity.With(labels).Set(toFloat(capacity))
+	networkGlobalUsed.With(labels).Set(toFloat(used))
+}
+
+// forgetNetworkGlobalMetrics drops the series of a NetworkGlobal that is gone,
+// or of the families it no longer has address spaces of.
+func forgetNetworkGlobalMetrics(key types.NamespacedName, families ...string) {
+	if len(families) == 0 {
+		families = []string{corev1.SubnetTypeIPv4, corev1.SubnetTypeIPv6}
+	}
+	for _, family := range families {
+		labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name, "family": family}
+		networkGlobalCapacity.Delete(labels)
+		networkGlobalUsed.Delete(labels)
+	}
+}
+
+func equalLabels(a, b prometheus.Labels) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// toFloat converts i to a float64, which holds even the size of an IPv6 prefix.
+func toFloat(i *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return f
+}
@@ -0,0 +1,316 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	netGloControllers "gardener/networkGlobal/controllers"
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
+)
+
+// The objects of the network.onmetal.de group share their spec and status
+// with the legacy ones, but their status isn't computed yet. The reconcilers
+// below keep their finalizers, so that NetworkGlobals and Subnets of the group
+// aren't deleted while objects of the group still use them.
+
+// MigratedNetworkGlobalReconciler keeps the finalizer of the network.onmetal.de
+// NetworkGlobals and holds back their deletion while Subnets reference them.
+type MigratedNetworkGlobalReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Log      logr.Logger
+}
+
+// +kubebuilder:rbac:groups=network.onmetal.de,resources=networkglobals,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=network.onmetal.de,resources=networkglobals/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=network.onmetal.de,resources=subnets,verbs=get;list;watch;delete
+
+func (r *MigratedNetworkGlobalReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("networkglobal", req.NamespacedName)
+
+	nGlobal := &v1alpha1.NetworkGlobal{}
+	if err := r.Get(ctx, req.NamespacedName, nGlobal); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if nGlobal.DeletionTimestamp.IsZero() {
+		if err := patchFinalizer(ctx, r, nGlobal, v1alpha1.NetworkGlobalFinalizer, true); err != nil {
+			log.Error(err, "Can't add the finalizer", "NetworkGlobal", nGlobal.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	subnets, err := r.dependentSubnets(ctx, nGlobal)
+	if err != nil {
+		log.Error(err, "Couldn't list the dependent subnets", "NetworkGlobal", nGlobal.Name)
+		return ctrl.Result{}, err
+	}
+	if len(subnets) == 0 {
+		if err := patchFinalizer(ctx, r, nGlobal, v1alpha1.NetworkGlobalFinalizer, false); err != nil {
+			log.Error(err, "Couldn't delete the finalizer", "NetworkGlobal", nGlobal.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	var names []string
+	for i := range subnets {
+		subnet := &subnets[i]
+		names = append(names, subnet.Name)
+		if nGlobal.Spec.DeletionPolicy != netGlo.DeletionPolicyCascade || !subnet.DeletionTimestamp.IsZero() {
+			continue
+		}
+		log.Info("Deleting the Subnet of the NetworkGlobal", "NetworkGlobal", nGlobal.Name, "Subnet", subnet.Name)
+		if err := r.Delete(ctx, subnet); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Couldn't delete the subnet", "NetworkGlobal", nGlobal.Name, "Subnet", subnet.Name)
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(nGlobal, corev1api.EventTypeNormal, "DeletingSubnet", "Deleting subnet %q as the deletion policy is Cascade", subnet.Name)
+	}
+	message, blocked, changed := netGloControllers.SetDeletionBlocked(&nGlobal.Status, nGlobal.Spec.DeletionPolicy, nGlobal.Generation, names)
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+	if blocked {
+		r.Recorder.Event(nGlobal, corev1api.EventTypeWarning, "DeletionBlocked", message)
+	}
+	if err := r.Status().Update(ctx, nGlobal); err != nil {
+		log.Error(err, "Couldn't block the deletion", "NetworkGlobal", nGlobal.Name)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// dependentSubnets returns the Subnets of the group referencing the NetworkGlobal by its ID.
+func (r *MigratedNetworkGlobalReconciler) dependentSubnets(ctx context.Context, nGlobal *v1alpha1.NetworkGlobal) ([]v1alpha1.Subnet, error) {
+	if nGlobal.Spec.ID == "" {
+		return nil, nil
+	}
+	subnets := &v1alpha1.SubnetList{}
+	if err := r.List(ctx, subnets, client.InNamespace(nGlobal.Namespace)); err != nil {
+		return nil, err
+	}
+	var dependents []v1alpha1.Subnet
+	for _, subnet := range subnets.Items {
+		if subnet.Spec.NetworkGlobalID == nGlobal.Spec.ID {
+			dependents = append(dependents, subnet)
+		}
+	}
+	return dependents, nil
+}
+
+func (r *MigratedNetworkGlobalReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// The legacy kinds have controllers of the same names.
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("migrated-networkglobal").
+		For(&v1alpha1.NetworkGlobal{}).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &v1alpha1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.subnetNetworkGlobalRequests),
+		}).
+		Complete(r)
+}
+
+// subnetNetworkGlobalRequests maps a Subnet to a request for the NetworkGlobal
+// it references, whose deletion may be waiting for it.
+func (r *MigratedNetworkGlobalReconciler) subnetNetworkGlobalRequests(a handler.MapObject) []reconcile.Request {
+	subnet, ok := a.Object.(*v1alpha1.Subnet)
+	if !ok || subnet.Spec.NetworkGlobalID == "" {
+		return nil
+	}
+	nGlobals := &v1alpha1.NetworkGlobalList{}
+	if err := r.List(context.Background(), nGlobals, client.InNamespace(subnet.Namespace)); err != nil {
+		r.Log.Error(err, "Couldn't look up the NetworkGlobal", "ID", subnet.Spec.NetworkGlobalID)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, nGlobal := range nGlobals.Items {
+		if nGlobal.Spec.ID == subnet.Spec.NetworkGlobalID {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Name: nGlobal.Name, Namespace: nGlobal.Namespace}})
+		}
+	}
+	return requests
+}
+
+// MigratedSubnetReconciler keeps the finalizer of the network.onmetal.de
+// Subnets and holds back their deletion while child Subnets or IPAddressClaims
+// of the group use them.
+type MigratedSubnetReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Log      logr.Logger
+}
+
+// +kubebuilder:rbac:groups=network.onmetal.de,resources=subnets,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=network.onmetal.de,resources=subnets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=network.onmetal.de,resources=ipaddressclaims,verbs=get;list;watch
+
+func (r *MigratedSubnetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("subnet", req.NamespacedName)
+
+	subnet := &v1alpha1.Subnet{}
+	if err := r.Get(ctx, req.NamespacedName, subnet); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if subnet.DeletionTimestamp.IsZero() {
+		if err := patchFinalizer(ctx, r, subnet, v1alpha1.SubnetFinalizer, true); err != nil {
+			log.Error(err, "Can't add the finalizer", "Subnet", subnet.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	children, claims, err := r.subnetDependents(ctx, subnet)
+	if err != nil {
+		log.Error(err, "Couldn't list the dependents", "Subnet", subnet.Name)
+		return ctrl.Result{}, err
+	}
+	if len(children) == 0 && len(claims) == 0 {
+		if err := patchFinalizer(ctx, r, subnet, v1alpha1.SubnetFinalizer, false); err != nil {
+			log.Error(err, "Couldn't delete the finalizer", "Subnet", subnet.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	message, blocked, changed := setDeletionBlocked(&subnet.Status, subnet.Generation, children, claims)
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+	if blocked {
+		r.Recorder.Event(subnet, corev1api.EventTypeWarning, "DeletionBlocked", message)
+	}
+	if err := r.Status().Update(ctx, subnet); err != nil {
+		log.Error(err, "Couldn't block the deletion", "Subnet", subnet.Name)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// subnetDependents returns the names of the child Subnets and IPAddressClaims
+// of the group that keep the Subnet from being deleted.
+func (r *MigratedSubnetReconciler) subnetDependents(ctx context.Context, subnet *v1alpha1.Subnet) ([]string, []string, error) {
+	subnets := &v1alpha1.SubnetList{}
+	if err := r.List(ctx, subnets, client.InNamespace(subnet.Namespace)); err != nil {
+		return nil, nil, err
+	}
+	claims := &v1alpha1.IPAddressClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(subnet.Namespace)); err != nil {
+		return nil, nil, err
+	}
+
+	var children, claimNames []string
+	for _, child := range subnets.Items {
+		if child.Spec.SubnetParentID == subnet.Name {
+			children = append(children, child.Name)
+		}
+	}
+	for _, claim := range claims.Items {
+		if claimTakesFrom(&claim.Spec, subnet) {
+			claimNames = append(claimNames, claim.Name)
+		}
+	}
+	return children, claimNames, nil
+}
+
+func (r *MigratedSubnetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("migrated-subnet").
+		For(&v1alpha1.Subnet{}).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &v1alpha1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(migratedParentRequests),
+		}).
+		Watches(&source.Kind{Type: &v1alpha1.IPAddressClaim{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(migratedClaimSubnetRequests),
+		}).
+		Complete(r)
+}
+
+// migratedParentRequests maps a Subnet to a request for its parent, whose
+// deletion may be waiting for it.
+func migratedParentRequests(a handler.MapObject) []reconcile.Request {
+	subnet, ok := a.Object.(*v1alpha1.Subnet)
+	if !ok || subnet.Spec.SubnetParentID == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: subnet.Spec.SubnetParentID, Namespace: subnet.Namespace}}}
+}
+
+// migratedClaimSubnetRequests maps an IPAddressClaim to requests for the
+// Subnets it may take its address from.
+func migratedClaimSubnetRequests(a handler.MapObject) []reconcile.Request {
+	claim, ok := a.Object.(*v1alpha1.IPAddressClaim)
+	if !ok {
+		return nil
+	}
+	var names []string
+	if claim.Spec.SubnetID != "" {
+		names = append(names, claim.Spec.SubnetID)
+	}
+	if claim.Spec.DualStackSubnetID != "" {
+		for _, family := range corev1.DualStackSubnetFamilies {
+			names = append(names, corev1.DualStackSubnetMemberName(claim.Spec.DualStackSubnetID, family))
+		}
+	}
+	var requests []reconcile.Request
+	for _, name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Name: name, Namespace: claim.Namespace}})
+	}
+	return requests
+}
+
+// finalizedObject is an object of the group whose finalizer is kept.
+type finalizedObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// patchFinalizer adds the finalizer to the object, or removes it if present is false.
+func patchFinalizer(ctx context.Context, c client.Client, obj finalizedObject, finalizer string, present bool) error {
+	finalizers := sets.NewString(obj.GetFinalizers()...)
+	if finalizers.Has(finalizer) == present {
+		return nil
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject())
+	if present {
+		finalizers.Insert(finalizer)
+	} else {
+		finalizers.Delete(finalizer)
+	}
+	obj.SetFinalizers(finalizers.List())
+	return client.IgnoreNotFound(c.Patch(ctx, obj, patch))
+}
@@ -0,0 +1,110 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+var _ = Describe("The network.onmetal.de controllers", func() {
+	ctx := context.Background()
+
+	finalizers := func(obj finalizedObject) func() []string {
+		return func() []string {
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: obj.GetName(), Namespace: testNamespace}, obj); err != nil {
+				return nil
+			}
+			return obj.GetFinalizers()
+		}
+	}
+	exists := func(obj finalizedObject) func() bool {
+		return func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: obj.GetName(), Namespace: testNamespace}, obj.DeepCopyObject())
+			return err == nil
+		}
+	}
+	createMigratedSubnet := func(name, networkGlobalID, parent, cidr string) *v1alpha1.Subnet {
+		subnet := &v1alpha1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: corev1.SubnetSpec{
+				Type:            corev1.SubnetTypeIPv4,
+				CIDR:            cidr,
+				NetworkGlobalID: networkGlobalID,
+				SubnetParentID:  parent,
+			},
+		}
+		ExpectWithOffset(1, k8sClient.Create(ctx, subnet)).To(Succeed())
+		return subnet
+	}
+
+	It("hold back the deletion of a networkGlobal while subnets reference it", func() {
+		nGlobal := &v1alpha1.NetworkGlobal{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrated-ng", Namespace: testNamespace},
+			Spec:       netGlo.NetworkGlobalSpec{ID: "migrated-ng"},
+		}
+		Expect(k8sClient.Create(ctx, nGlobal)).To(Succeed())
+		subnet := createMigratedSubnet("migrated-ng", "migrated-ng", "", "10.60.0.0/24")
+		Eventually(finalizers(nGlobal), timeout, interval).Should(ContainElement(v1alpha1.NetworkGlobalFinalizer))
+		Eventually(finalizers(subnet), timeout, interval).Should(ContainElement(v1alpha1.SubnetFinalizer))
+
+		Expect(k8sClient.Delete(ctx, nGlobal)).To(Succeed())
+		Eventually(func() metav1.ConditionStatus {
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: nGlobal.Name, Namespace: testNamespace}, nGlobal); err != nil {
+				return metav1.ConditionUnknown
+			}
+			if condition := nGlobal.Status.GetCondition(netGlo.NetworkGlobalDeletionBlocked); condition != nil {
+				return condition.Status
+			}
+			return metav1.ConditionUnknown
+		}, timeout, interval).Should(Equal(metav1.ConditionTrue))
+		Consistently(exists(nGlobal), time.Second, interval).Should(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, subnet)).To(Succeed())
+		Eventually(exists(subnet), timeout, interval).Should(BeFalse())
+		Eventually(exists(nGlobal), timeout, interval).Should(BeFalse())
+	})
+
+	It("hold back the deletion of a subnet while it has children", func() {
+		parent := createMigratedSubnet("migrated-parent", "migrated-net", "", "10.61.0.0/16")
+		child := createMigratedSubnet("migrated-child", "migrated-net", "migrated-parent", "10.61.1.0/24")
+		Eventually(finalizers(parent), timeout, interval).Should(ContainElement(v1alpha1.SubnetFinalizer))
+
+		Expect(k8sClient.Delete(ctx, parent)).To(Succeed())
+		Eventually(func() bool {
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: parent.Name, Namespace: testNamespace}, parent); err != nil {
+				return false
+			}
+			return isConditionTrue(&parent.Status, corev1.SubnetDeletionBlocked)
+		}, timeout, interval).Should(BeTrue())
+		Consistently(exists(parent), time.Second, interval).Should(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, child)).To(Succeed())
+		Eventually(exists(child), timeout, interval).Should(BeFalse())
+		Eventually(exists(parent), timeout, interval).Should(BeFalse())
+	})
+})
@@ -0,0 +1,124 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+// OrphanSweeper periodically looks for Subnets whose NetworkGlobal has
+// disappeared. It flags them with the Orphaned condition and, if Delete is
+// set, deletes them once they have been orphaned for longer than GracePeriod.
+type OrphanSweeper struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+
+	// Interval is the time between two sweeps.
+	Interval time.Duration
+	// GracePeriod is how long a Subnet stays orphaned before it is deleted.
+	GracePeriod time.Duration
+	// Delete enables the deletion of orphaned Subnets.
+	Delete bool
+}
+
+// Start runs the sweeper until stop is closed.
+func (s *OrphanSweeper) Start(stop <-chan struct{}) error {
+	wait.Until(func() {
+		if err := s.sweep(context.Background()); err != nil {
+			s.Log.Error(err, "Couldn't sweep the orphaned subnets")
+		}
+	}, s.Interval, stop)
+	return nil
+}
+
+// NeedLeaderElection makes the sweeper run on the leader only.
+func (s *OrphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+func (s *OrphanSweeper) sweep(ctx context.Context) error {
+	subnets := &corev1.SubnetList{}
+	if err := s.List(ctx, subnets); err != nil {
+		return err
+	}
+	for i := range subnets.Items {
+		subnet := &subnets.Items[i]
+		if !subnet.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := s.sweepSubnet(ctx, subnet); err != nil {
+			s.Log.Error(err, "Couldn't sweep the subnet", "Subnet", subnet.Name)
+		}
+	}
+	return nil
+}
+
+func (s *OrphanSweeper) sweepSubnet(ctx context.Context, subnet *corev1.Subnet) error {
+	resolved := subnet.Status.GetCondition(corev1.SubnetNetworkGlobalResolved)
+	orphaned := resolved != nil && resolved.Status == metav1.ConditionFalse &&
+		resolved.Reason == corev1.SubnetReasonNetworkGlobalGone
+
+	if !orphaned {
+		if !isConditionTrue(&subnet.Status, corev1.SubnetOrphaned) {
+			return nil
+		}
+		subnet.Status.SetCondition(corev1.SubnetCondition{
+			Type:               corev1.SubnetOrphaned,
+			Status:             metav1.ConditionFalse,
+			Reason:             "NetworkGlobalResolved",
+			ObservedGeneration: subnet.Generation,
+		})
+		return s.Status().Update(ctx, subnet)
+	}
+
+	if s.Delete && time.Since(resolved.LastTransitionTime.Time) >= s.GracePeriod {
+		s.Log.Info("Deleting the orphaned Subnet", "Name", subnet.Name, "NetworkGlobal", subnet.Spec.NetworkGlobalID)
+		if err := s.Client.Delete(ctx, subnet); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		s.Recorder.Eventf(subnet, corev1api.EventTypeNormal, "OrphanDeleted",
+			"deleted because networkGlobal %q disappeared", subnet.Spec.NetworkGlobalID)
+		return nil
+	}
+
+	if isConditionTrue(&subnet.Status, corev1.SubnetOrphaned) {
+		return nil
+	}
+	subnet.Status.SetCondition(corev1.SubnetCondition{
+		Type:               corev1.SubnetOrphaned,
+		Status:             metav1.ConditionTrue,
+		Reason:             corev1.SubnetReasonNetworkGlobalGone,
+		Message:            resolved.Message,
+		ObservedGeneration: subnet.Generation,
+	})
+	if err := s.Status().Update(ctx, subnet); err != nil {
+		return err
+	}
+	s.Recorder.Event(subnet, corev1api.EventTypeWarning, "Orphaned", resolved.Message)
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+var _ = Describe("OrphanSweeper", func() {
+	ctx := context.Background()
+
+	// removeNetworkGlobal deletes the NetworkGlobal underneath its Subnets,
+	// bypassing the NetworkGlobal controller that would block it.
+	removeNetworkGlobal := func(name string) {
+		nGlobal := createNetworkGlobal(name, "10.30.0.0/16")
+		createSubnet(name, name, "", "10.30.0.0/24")
+		Eventually(subnetState(name), timeout, interval).Should(Equal(corev1.SubnetStateValid))
+
+		Expect(k8sClient.Delete(ctx, nGlobal)).To(Succeed())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: testNamespace}, nGlobal)
+			if err == nil && len(nGlobal.Finalizers) > 0 {
+				patch := client.MergeFrom(nGlobal.DeepCopy())
+				nGlobal.Finalizers = nil
+				Expect(client.IgnoreNotFound(k8sClient.Patch(ctx, nGlobal, patch))).To(Succeed())
+			}
+			return apierrors.IsNotFound(err)
+		}, timeout, interval).Should(BeTrue())
+
+		Eventually(func() string {
+			resolved := getSubnet(name).Status.GetCondition(corev1.SubnetNetworkGlobalResolved)
+			if resolved == nil {
+				return ""
+			}
+			return resolved.Reason
+		}, timeout, interval).Should(Equal(corev1.SubnetReasonNetworkGlobalGone))
+	}
+
+	newSweeper := func(gracePeriod time.Duration, deleteOrphans bool) *OrphanSweeper {
+		return &OrphanSweeper{
+			Client:      k8sClient,
+			Log:         ctrl.Log.WithName("controllers").WithName("OrphanSweeper"),
+			Recorder:    record.NewFakeRecorder(100),
+			GracePeriod: gracePeriod,
+			Delete:      deleteOrphans,
+		}
+	}
+	orphanedStatus := func(sweeper *OrphanSweeper, name string) func() metav1.ConditionStatus {
+		return func() metav1.ConditionStatus {
+			Expect(sweeper.sweep(ctx)).To(Succeed())
+			orphaned := getSubnet(name).Status.GetCondition(corev1.SubnetOrphaned)
+			if orphaned == nil {
+				return metav1.ConditionUnknown
+			}
+			return orphaned.Status
+		}
+	}
+
+	It("only flags orphaned subnets unless deleting them is enabled", func() {
+		removeNetworkGlobal("orphan-flagged")
+
+		sweeper := newSweeper(0, false)
+		Eventually(orphanedStatus(sweeper, "orphan-flagged"), timeout, interval).Should(Equal(metav1.ConditionTrue))
+		Consistently(subnetExists("orphan-flagged"), time.Second, interval).Should(BeTrue())
+	})
+
+	It("deletes orphaned subnets once the grace period is over", func() {
+		removeNetworkGlobal("orphan-deleted")
+
+		sweeper := newSweeper(time.Hour, true)
+		Eventually(orphanedStatus(sweeper, "orphan-deleted"), timeout, interval).Should(Equal(metav1.ConditionTrue))
+		Consistently(subnetExists("orphan-deleted"), time.Second, interval).Should(BeTrue())
+
+		sweeper.GracePeriod = 0
+		Expect(sweeper.sweep(ctx)).To(Succeed())
+		Eventually(subnetExists("orphan-deleted"), timeout, interval).Should(BeFalse())
+	})
+})
@@ -0,0 +1,109 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+
+	netGlo "gardener/networkGlobal/api/v1"
+)
+
+// updateOwnerReferences makes the parent Subnet, or the NetworkGlobal for a
+// top-level Subnet, the owner of the Subnet, so that the garbage collector
+// cleans up after them. References are only changed once the new owner
+// resolves, so a vanished owner stays recorded on the Subnet.
+func (r *SubnetReconciler) updateOwnerReferences(ctx context.Context, subnet *corev1.Subnet) error {
+	owner, err := r.subnetOwner(ctx, subnet)
+	if err != nil || owner == nil {
+		return err
+	}
+
+	var refs []metav1.OwnerReference
+	for _, ref := range subnet.OwnerReferences {
+		if !isReferenceOwner(ref) {
+			refs = append(refs, ref)
+		}
+	}
+	refs = append(refs, *owner)
+
+	if equalOwnerReferences(refs, subnet.OwnerReferences) {
+		return nil
+	}
+	patch := client.MergeFrom(subnet.DeepCopy())
+	subnet.OwnerReferences = refs
+	return r.Patch(ctx, subnet, patch)
+}
+
+// subnetOwner returns the owner reference the Subnet should carry, or nil if
+// the object it references doesn't exist.
+func (r *SubnetReconciler) subnetOwner(ctx context.Context, subnet *corev1.Subnet) (*metav1.OwnerReference, error) {
+	if parentName := subnet.Spec.SubnetParentID; parentName != "" {
+		parent := &corev1.Subnet{}
+		if err := r.Get(ctx, client.ObjectKey{Name: parentName, Namespace: subnet.Namespace}, parent); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		return ownerReference(corev1.GroupVersion.String(), "Subnet", parent), nil
+	}
+	if subnet.Spec.NetworkGlobalID == "" {
+		return nil, nil
+	}
+	nGlobal, err := getNetworkGlobal(ctx, r, subnet.Namespace, subnet.Spec.NetworkGlobalID)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ownerReference(netGlo.GroupVersion.String(), "NetworkGlobal", nGlobal), nil
+}
+
+func ownerReference(apiVersion, kind string, owner metav1.Object) *metav1.OwnerReference {
+	blockOwnerDeletion := true
+	return &metav1.OwnerReference{
+		APIVersion:         apiVersion,
+		Kind:               kind,
+		Name:               owner.GetName(),
+		UID:                owner.GetUID(),
+		BlockOwnerDeletion: &blockOwnerDeletion,
+	}
+}
+
+// isReferenceOwner reports whether the owner reference was set by
+// updateOwnerReferences.
+func isReferenceOwner(ref metav1.OwnerReference) bool {
+	return (ref.APIVersion == corev1.GroupVersion.String() && ref.Kind == "Subnet") ||
+		(ref.APIVersion == netGlo.GroupVersion.String() && ref.Kind == "NetworkGlobal")
+}
+
+func equalOwnerReferences(a, b []metav1.OwnerReference) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].APIVersion != b[i].APIVersion || a[i].Kind != b[i].Kind ||
+			a[i].Name != b[i].Name || a[i].UID != b[i].UID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1 "gardener/subnet/api/v1"
+)
+
+var _ = Describe("Subnet owner references", func() {
+	ctx := context.Background()
+
+	ownerKinds := func(name string) func() []string {
+		return func() []string {
+			var kinds []string
+			for _, ref := range getSubnet(name).OwnerReferences {
+				kinds = append(kinds, ref.Kind+"/"+ref.Name)
+			}
+			return kinds
+		}
+	}
+
+	It("makes the NetworkGlobal own top-level Subnets and parents own their children", func() {
+		nGlobal := createNetworkGlobal("owners", "10.20.0.0/16")
+		createSubnet("owners-root", "owners", "", "10.20.0.0/20")
+		createSubnet("owners-child", "owners", "owners-root", "10.20.1.0/24")
+
+		Eventually(ownerKinds("owners-root"), timeout, interval).Should(ConsistOf("NetworkGlobal/owners"))
+		Eventually(ownerKinds("owners-child"), timeout, interval).Should(ConsistOf("Subnet/owners-root"))
+
+		ref := getSubnet("owners-root").OwnerReferences[0]
+		Expect(ref.UID).To(Equal(nGlobal.UID))
+		Expect(ref.Controller).To(BeNil())
+		Expect(ref.BlockOwnerDeletion).NotTo(BeNil())
+		Expect(*ref.BlockOwnerDeletion).To(BeTrue())
+	})
+
+	It("leaves owner references of other controllers alone", func() {
+		createNetworkGlobal("foreign-owners", "10.21.0.0/16", "fd00:21::/48")
+		dualStack := &corev1.DualStackSubnet{
+			ObjectMeta: metav1.ObjectMeta{Name: "foreign-owners", Namespace: testNamespace},
+			Spec: corev1.DualStackSubnetSpec{
+				NetworkGlobalID: "foreign-owners",
+				IPv4:            corev1.DualStackFamilySpec{CIDR: "10.21.0.0/24"},
+				IPv6:            corev1.DualStackFamilySpec{CIDR: "fd00:21::/64"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, dualStack)).To(Succeed())
+
+		// The Subnet looks like the one the DualStackSubnet controller creates.
+		controllerRef := true
+		subnet := &corev1.Subnet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      corev1.DualStackSubnetMemberName(dualStack.Name, corev1.SubnetTypeIPv4),
+				Namespace: testNamespace,
+				Labels:    map[string]string{corev1.DualStackSubnetLabel: dualStack.Name},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: corev1.GroupVersion.String(),
+					Kind:       "DualStackSubnet",
+					Name:       dualStack.Name,
+					UID:        dualStack.UID,
+					Controller: &controllerRef,
+				}},
+			},
+			Spec: corev1.SubnetSpec{Type: corev1.SubnetTypeIPv4, CIDR: "10.21.0.0/24", NetworkGlobalID: "foreign-owners"},
+		}
+		Expect(k8sClient.Create(ctx, subnet)).To(Succeed())
+
+		Eventually(ownerKinds("foreign-owners-ipv4"), timeout, interval).Should(ConsistOf(
+			"DualStackSubnet/foreign-owners", "NetworkGlobal/foreign-owners"))
+		Consistently(ownerKinds("foreign-owners-ipv4"), time.Second, interval).Should(ContainElement("DualStackSubnet/foreign-owners"))
+	})
+})
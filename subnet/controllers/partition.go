@@ -0,0 +1,86 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// checkSubnetPartition checks that the Partition of the Subnet exists, offers
+// its address family and that the Subnet keeps clear of the reserved ranges.
+func (r *SubnetReconciler) checkSubnetPartition(ctx context.Context, subnet *corev1.Subnet) (*subnetProblem, error) {
+	if subnet.Spec.PartitionID == "" {
+		return nil, nil
+	}
+	partition := &corev1.Partition{}
+	if err := r.Get(ctx, client.ObjectKey{Name: subnet.Spec.PartitionID, Namespace: subnet.Namespace}, partition); err != nil {
+		if apierrors.IsNotFound(err) {
+			return pendingSubnet(corev1.SubnetReasonPartitionNotFound,
+				"partition %q doesn't exist", subnet.Spec.PartitionID), nil
+		}
+		return nil, err
+	}
+
+	ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+	family := subnet.Spec.Type
+	if err == nil {
+		family = ipam.Family(ipNet)
+	}
+	if family != "" && !partition.Spec.AllowsFamily(family) {
+		return invalidSubnet(corev1.SubnetReasonFamilyNotAvailable,
+			"partition %q doesn't offer %s", partition.Name, family), nil
+	}
+	if err != nil {
+		// checkSubnetHierarchy reports the broken or missing CIDR.
+		return nil, nil
+	}
+	for _, reserved := range partition.Spec.ReservedRanges {
+		if reservedNet, err := ipam.ParseCIDR(reserved); err == nil && ipam.Overlaps(ipNet, reservedNet) {
+			return invalidSubnet(corev1.SubnetReasonReservedRange,
+				"%s overlaps the reserved range %s of partition %q", ipNet, reservedNet, partition.Name), nil
+		}
+	}
+	return nil, nil
+}
+
+// partitionSubnetRequests maps a Partition to requests for the Subnets located
+// in it, whose validity depends on its families and reserved ranges.
+func (r *SubnetReconciler) partitionSubnetRequests(a handler.MapObject) []reconcile.Request {
+	name := a.Meta.GetName()
+	subnets, err := indexedSubnets(context.Background(), r, a.Meta.GetNamespace(), corev1.PartitionIDField, name)
+	if err != nil {
+		r.Log.Error(err, "Couldn't list the subnets of the partition", "Partition", name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, s := range subnets {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+		}})
+	}
+	return requests
+}
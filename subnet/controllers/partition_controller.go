@@ -0,0 +1,143 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// PartitionReconciler aggregates the Subnets located in a Partition into its status
+type PartitionReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=partitions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=partitions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets,verbs=get;list;watch
+
+func (r *PartitionReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("partition", req.NamespacedName)
+
+	partition := &corev1.Partition{}
+	if err := r.Get(ctx, req.NamespacedName, partition); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	subnets, err := indexedSubnets(ctx, r, partition.Namespace, corev1.PartitionIDField, partition.Name)
+	if err != nil {
+		log.Error(err, "Couldn't list the subnets", "Partition", partition.Name)
+		return ctrl.Result{}, err
+	}
+	byName := map[string]*corev1.Subnet{}
+	for i := range subnets {
+		byName[subnets[i].Name] = &subnets[i]
+	}
+
+	capacity := map[string]*big.Int{corev1.SubnetTypeIPv4: new(big.Int), corev1.SubnetTypeIPv6: new(big.Int)}
+	used := map[string]*big.Int{corev1.SubnetTypeIPv4: new(big.Int), corev1.SubnetTypeIPv6: new(big.Int)}
+	for i := range subnets {
+		subnet := &subnets[i]
+		// Only the outermost valid subnets of the partition add to the
+		// capacity, the space of their children is already part of it.
+		if subnet.Status.State != corev1.SubnetStateValid || hasCountedAncestor(subnet, byName) {
+			continue
+		}
+		family := subnetFamily(subnet)
+		if capacity[family] == nil {
+			continue
+		}
+		subnetCapacity := quantityToInt(subnet.Status.Capacity)
+		capacity[family].Add(capacity[family], subnetCapacity)
+		used[family].Add(used[family], subnetCapacity.Sub(subnetCapacity, quantityToInt(subnet.Status.CapacityLeft)))
+	}
+
+	status := corev1.PartitionStatus{Subnets: len(subnets)}
+	for _, family := range []string{corev1.SubnetTypeIPv4, corev1.SubnetTypeIPv6} {
+		if capacity[family].Sign() == 0 {
+			continue
+		}
+		*status.Family(family) = corev1.PartitionAddressUsage{
+			Capacity:        addressQuantity(capacity[family]),
+			Used:            addressQuantity(used[family]),
+			DisplayCapacity: ipam.FormatSize(capacity[family], family),
+			DisplayUsed:     ipam.FormatSize(used[family], family),
+		}
+	}
+	if equality.Semantic.DeepEqual(status, partition.Status) {
+		return ctrl.Result{}, nil
+	}
+	partition.Status = status
+	if err := r.Status().Update(ctx, partition); err != nil {
+		log.Error(err, "Couldn't update the status", "Partition", partition.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// hasCountedAncestor reports whether a valid ancestor of the Subnet is located
+// in the same Partition, so that it already counts the space of the Subnet.
+func hasCountedAncestor(subnet *corev1.Subnet, partitionSubnets map[string]*corev1.Subnet) bool {
+	seen := sets.NewString(subnet.Name)
+	for parent := partitionSubnets[subnet.Spec.SubnetParentID]; parent != nil && !seen.Has(parent.Name); parent = partitionSubnets[parent.Spec.SubnetParentID] {
+		if parent.Status.State == corev1.SubnetStateValid {
+			return true
+		}
+		seen.Insert(parent.Name)
+	}
+	return false
+}
+
+func (r *PartitionReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Partition{}).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &corev1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(subnetPartitionRequests),
+		}).
+		Complete(r)
+}
+
+// subnetPartitionRequests maps a Subnet to a request for the Partition it is
+// located in, whose status aggregates it.
+func subnetPartitionRequests(a handler.MapObject) []reconcile.Request {
+	subnet, ok := a.Object.(*corev1.Subnet)
+	if !ok || subnet.Spec.PartitionID == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{
+		Name:      subnet.Spec.PartitionID,
+		Namespace: subnet.Namespace,
+	}}}
+}
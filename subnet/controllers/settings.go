@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// effectiveGateway returns the gateway of the Subnet, the first usable host if
+// none is set, or nil if the Subnet has no CIDR yet or no address to spare.
+func effectiveGateway(subnet *corev1.Subnet) net.IP {
+	ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+	if err != nil {
+		return nil
+	}
+	if subnet.Spec.Gateway != "" {
+		return net.ParseIP(subnet.Spec.Gateway)
+	}
+	return ipam.DefaultGateway(ipNet)
+}
+
+// checkSubnetGateway checks that the gateway is a usable address of the Subnet.
+// The webhook can't check it before the Subnet is carved.
+func checkSubnetGateway(subnet *corev1.Subnet) *subnetProblem {
+	ipNet, err := ipam.ParseCIDR(subnet.Spec.CIDR)
+	if err != nil || subnet.Spec.Gateway == "" {
+		return nil
+	}
+	if ip := net.ParseIP(subnet.Spec.Gateway); ip == nil || !ipam.Usable(ipNet).Has(ip) {
+		return invalidSubnet(corev1.SubnetReasonInvalidGateway,
+			"gateway %s is not a usable address of %s", subnet.Spec.Gateway, ipNet)
+	}
+	return nil
+}
+
+// setNetworkSettings writes the effective gateway, name servers, search
+// domains, MTU and VLAN of the Subnet into the status. Settings the Subnet
+// doesn't set are taken from the status of its parent, so they are passed
+// down the whole hierarchy.
+func (r *SubnetReconciler) setNetworkSettings(ctx context.Context, subnet *corev1.Subnet, status *corev1.SubnetStatus) error {
+	status.Gateway = ""
+	if gateway := effectiveGateway(subnet); gateway != nil {
+		status.Gateway = gateway.String()
+	}
+	status.DNSServers = subnet.Spec.DNSServers
+	status.SearchDomains = subnet.Spec.SearchDomains
+	status.MTU = subnet.Spec.MTU
+	status.VLANID = subnet.Spec.VLANID
+
+	if subnet.Spec.SubnetParentID == "" {
+		return nil
+	}
+	parent := &corev1.Subnet{}
+	if err := r.Get(ctx, client.ObjectKey{Name: subnet.Spec.SubnetParentID, Namespace: subnet.Namespace}, parent); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if len(status.DNSServers) == 0 {
+		status.DNSServers = parent.Status.DNSServers
+	}
+	if len(status.SearchDomains) == 0 {
+		status.SearchDomains = parent.Status.SearchDomains
+	}
+	if status.MTU == 0 {
+		status.MTU = parent.Status.MTU
+	}
+	if status.VLANID == 0 {
+		status.VLANID = parent.Status.VLANID
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/ipam"
+)
+
+// subnetProblem explains why a Subnet is Pending or Invalid.
+type subnetProblem struct {
+	state   corev1.SubnetState
+	reason  string
+	message string
+}
+
+func pendingSubnet(reason, format string, args ...interface{}) *subnetProblem {
+	return &subnetProblem{state: corev1.SubnetStatePending, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+func invalidSubnet(reason, format string, args ...interface{}) *subnetProblem {
+	return &subnetProblem{state: corev1.SubnetStateInvalid, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// checkSubnet checks the references and the place of the Subnet in the address
+// plan. It returns nil if the Subnet is valid.
+func (r *SubnetReconciler) checkSubnet(ctx context.Context, subnet *corev1.Subnet) (*subnetProblem, error) {
+	ok, err := r.IsNetworkGlobalIDValid(subnet)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return pendingSubnet(corev1.SubnetReasonNetworkGlobalNotFound,
+			"networkGlobal %q doesn't exist", subnet.Spec.NetworkGlobalID), nil
+	}
+	problem, err := r.checkSubnetPartition(ctx, subnet)
+	if problem != nil || err != nil {
+		return problem, err
+	}
+	problem, err = r.checkSubnetHierarchy(ctx, subnet)
+	if problem != nil || err != nil {
+		return problem, err
+	}
+	return checkSubnetGateway(subnet), nil
+}
+
+// updateSubnetStatus recomputes the state, the conditions, the capacity and the
+// network settings of the Subnet and writes them through the status subresource if they changed.
+// The problem carving ran into, if any, takes the place of the one checkSubnet
+// guesses for a Subnet still waiting for its block.
+func (r *SubnetReconciler) updateSubnetStatus(ctx context.Context, subnet *corev1.Subnet, carveProblem *subnetProblem) error {
+	status := subnet.Status.DeepCopy()
+
+	problem, err := r.checkSubnet(ctx, subnet)
+	if err != nil {
+		return err
+	}
+	if carveProblem != nil && needsCarving(subnet) && (problem == nil || problem.reason == corev1.SubnetReasonParentExhausted) {
+		problem = carveProblem
+	}
+	if problem != nil {
+		status.State = problem.state
+		status.Reason = problem.reason
+		status.Message = problem.message
+	} else {
+		status.State = corev1.SubnetStateValid
+		status.Reason = ""
+		status.Message = ""
+	}
+
+	capacity, capacityLeft, err := r.subnetCapacity(ctx, subnet)
+	if err != nil {
+		return err
+	}
+	status.Capacity = addressQuantity(capacity)
+	status.CapacityLeft = addressQuantity(capacityLeft)
+	status.DisplayCapacity = ipam.FormatSize(capacity, subnetFamily(subnet))
+	status.DisplayCapacityLeft = ipam.FormatSize(capacityLeft, subnetFamily(subnet))
+	reportSubnetMetrics(subnet, capacity, capacityLeft)
+
+	status.FreeBlocks, status.DisplayFreeBlocks, err = r.subnetFreeBlocks(ctx, subnet)
+	if err != nil {
+		return err
+	}
+	if err := r.setNetworkSettings(ctx, subnet, status); err != nil {
+		return err
+	}
+
+	conditions, err := r.subnetConditions(ctx, subnet, problem, capacity, capacityLeft)
+	if err != nil {
+		return err
+	}
+	for _, condition := range conditions {
+		status.SetCondition(condition)
+	}
+	status.ObservedGeneration = subnet.Generation
+
+	if equality.Semantic.DeepEqual(*status, subnet.Status) {
+		return nil
+	}
+	if problem != nil {
+		r.Log.Info("Subnet is not valid", "Subnet", subnet.Name, "State", problem.state, "Reason", problem.message)
+	}
+
+	clone := subnet.DeepCopy()
+	clone.Status = *status
+	if err := r.Status().Update(ctx, clone); err != nil {
+		return err
+	}
+	r.recordStatusEvents(subnet, &subnet.Status, &clone.Status)
+	subnet.Status = clone.Status
+	return nil
+}
@@ -20,11 +20,14 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/event"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "gardener/subnet/api/v1"
 
@@ -32,87 +35,127 @@ import (
 )
 
 const (
-	SubnetFinalizerName = "core.gardener.cloud/networkglobal"
+	SubnetFinalizerName = "core.gardener.cloud/subnet"
+	// DeprecatedSubnetFinalizerName is the name the finalizer had before.
+	// It is renamed on the Subnets that still carry it.
+	DeprecatedSubnetFinalizerName = "core.gardener.cloud/networkglobal"
 )
 
 // SubnetReconciler reconciles a Subnet object
 type SubnetReconciler struct {
 	client.Client
-	Log           logr.Logger
-	Scheme        *runtime.Scheme
-	Manager       ctrl.Manager
-	Subnet        *corev1.Subnet
-	Request       ctrl.Request
-	NetworkGlobal netGlo.NetworkGlobal
+	// APIReader reads around the cache, so that a block carved a moment ago
+	// is never carved twice.
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Manager   ctrl.Manager
 }
 
 // +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=subnets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=ipaddressclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.gardener.cloud,resources=partitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.core.gardener.cloud,resources=networkglobals,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.core.gardener.cloud,resources=networkglobals/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *SubnetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
-	r.Request = req
 	log := r.Log.WithValues("subnet", req.NamespacedName)
 
 	reqLogger := r.Log.WithValues("Request.Name", req.Name)
-	reqLogger.Info("Reconciling Subnet Test")
+	reqLogger.Info("Reconciling the subnet")
 
-	r.Subnet = &corev1.Subnet{}
-
-	if err := r.Get(ctx, req.NamespacedName, r.Subnet); err != nil {
+	subnet := &corev1.Subnet{}
+	if err := r.Get(ctx, req.NamespacedName, subnet); err != nil {
 		log.Info("unable to fetch Subnet", "Subnet", req, "Error", err)
 		// we'll ignore not-found errors, since they can't be fixed by an immediate
 		// requeue (we'll need to wait for a new notification), and we can get them
 		// on deleted requests.
+		if apierrors.IsNotFound(err) {
+			forgetSubnetMetrics(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
 	// Add finalizer on the Subnet object if not added already.
-	if r.Subnet.DeletionTimestamp.IsZero() {
-		err := r.addSubnetFinalizer()
+	if subnet.DeletionTimestamp.IsZero() {
+		err := r.addSubnetFinalizer(ctx, subnet)
 		if err != nil {
-			log.Error(err, "Can't add the finalizer", "Subnet", r.Subnet.Name)
+			log.Error(err, "Can't add the finalizer", "Subnet", subnet.Name)
 			return ctrl.Result{}, err
 		}
 	}
 
 	// Deletion Flow
-	if !r.Subnet.DeletionTimestamp.IsZero() {
-		log.Info("Deleting the Subnet", "Name", r.Subnet.Name)
+	if !subnet.DeletionTimestamp.IsZero() {
+		log.Info("Deleting the Subnet", "Name", subnet.Name)
 
-		// Remove the finalizer
-		err := r.deleteSubnetFinalizers()
+		// Keep the finalizer while children or claims still take space of the Subnet.
+		children, claims, err := r.subnetDependents(ctx, subnet)
 		if err != nil {
-			log.Error(err, "Couldn't delete the finalizer", "Subnet", r.Subnet.Name)
+			log.Error(err, "Couldn't list the dependents", "Subnet", subnet.Name)
+			return ctrl.Result{}, err
+		}
+		if len(children) > 0 || len(claims) > 0 {
+			log.Info("Dependents still use the Subnet", "Name", subnet.Name, "Children", len(children), "Claims", len(claims))
+			if err := r.blockDeletion(ctx, subnet, children, claims); err != nil {
+				log.Error(err, "Couldn't block the deletion", "Subnet", subnet.Name)
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+			return ctrl.Result{}, nil
+		}
+
+		// Remove the finalizer
+		if err := r.deleteSubnetFinalizers(ctx, subnet); err != nil {
+			log.Error(err, "Couldn't delete the finalizer", "Subnet", subnet.Name)
 			return ctrl.Result{}, err
 		}
-		log.V(0).Info("Successfully deleted the Subnet", "Name", r.Subnet.Name)
+		forgetSubnetMetrics(req.NamespacedName)
+		log.V(0).Info("Successfully deleted the Subnet", "Name", subnet.Name)
 		return ctrl.Result{}, nil
 	}
 
-	return ctrl.Result{}, nil
-}
-
-func (r *SubnetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.updateOwnerReferences(ctx, subnet); err != nil {
+		log.Error(err, "Couldn't update the owner references", "Subnet", subnet.Name)
+		return ctrl.Result{}, err
+	}
 
-	predicateFunctions := predicate.Funcs{
-		CreateFunc: func(e event.CreateEvent) bool {
-			subnet := e.Object.(*corev1.Subnet)
+	var carveProblem *subnetProblem
+	if needsCarving(subnet) {
+		var err error
+		if carveProblem, err = r.carveSubnet(ctx, subnet); err != nil {
+			log.Error(err, "Couldn't carve the Subnet out of its parent", "Subnet", subnet.Name)
+			return ctrl.Result{}, err
+		}
+	}
 
-			if ok, err := r.IsNetworkGlobalIDValid(subnet); !ok {
-				r.Log.Error(err, "NetworkGlobalID is invalid, resource doesn't exist", "Subnet", subnet.Spec.NetworkGlobalID)
-				// TODO: take some action when this is invalid
-				return false
-			}
-			return true
-		},
-		DeleteFunc: func(e event.DeleteEvent) bool {
-			return false
-		},
+	if err := r.updateSubnetStatus(ctx, subnet, carveProblem); err != nil {
+		log.Error(err, "Couldn't update the status", "Subnet", subnet.Name)
+		return ctrl.Result{}, err
 	}
 
+	return ctrl.Result{}, nil
+}
+
+func (r *SubnetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Subnet{}).
-		WithEventFilter(predicateFunctions).
+		WithOptions(options).
+		Watches(&source.Kind{Type: &corev1.Subnet{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.relatedSubnetRequests),
+		}).
+		Watches(&source.Kind{Type: &netGlo.NetworkGlobal{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.dependentSubnetRequests),
+		}).
+		Watches(&source.Kind{Type: &corev1.IPAddressClaim{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(claimSubnetRequests),
+		}).
+		Watches(&source.Kind{Type: &corev1.Partition{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.partitionSubnetRequests),
+		}).
 		Complete(r)
 }
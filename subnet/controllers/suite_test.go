@@ -17,20 +17,29 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
+	"gardener/subnet/ipam"
+
+	netGlo "gardener/networkGlobal/api/v1"
+	netGloControllers "gardener/networkGlobal/controllers"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -40,6 +49,7 @@ import (
 var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
+var stopManager chan struct{}
 
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -64,6 +74,10 @@ var _ = BeforeSuite(func(done Done) {
 
 	err = corev1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = netGlo.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+	err = v1alpha1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
@@ -71,11 +85,113 @@ var _ = BeforeSuite(func(done Done) {
 	Expect(err).ToNot(HaveOccurred())
 	Expect(k8sClient).ToNot(BeNil())
 
+	By("starting the controllers")
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme.Scheme, MetricsBindAddress: "0"})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(netGloControllers.SetupIndexes(mgr)).To(Succeed())
+	Expect(SetupIndexes(mgr)).To(Succeed())
+	Expect((&netGloControllers.NetworkGlobalReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("networkglobal-controller"),
+		Log:      ctrl.Log.WithName("controllers").WithName("NetworkGlobal"),
+		Scheme:   mgr.GetScheme(),
+	}).SetupWithManager(mgr, controller.Options{})).To(Succeed())
+	Expect((&SubnetReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  mgr.GetEventRecorderFor("subnet-controller"),
+		Log:       ctrl.Log.WithName("controllers").WithName("Subnet"),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr, controller.Options{})).To(Succeed())
+	Expect((&IPAddressClaimReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("controllers").WithName("IPAddressClaim"),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr, controller.Options{})).To(Succeed())
+	Expect((&DualStackSubnetReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("DualStackSubnet"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr, controller.Options{})).To(Succeed())
+	Expect((&MigratedNetworkGlobalReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("migrated-networkglobal-controller"),
+		Log:      ctrl.Log.WithName("controllers").WithName("MigratedNetworkGlobal"),
+	}).SetupWithManager(mgr, controller.Options{})).To(Succeed())
+	Expect((&MigratedSubnetReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("migrated-subnet-controller"),
+		Log:      ctrl.Log.WithName("controllers").WithName("MigratedSubnet"),
+	}).SetupWithManager(mgr, controller.Options{})).To(Succeed())
+
+	stopManager = make(chan struct{})
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(stopManager)).To(Succeed())
+	}()
+
 	close(done)
 }, 60)
 
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
+	if stopManager != nil {
+		close(stopManager)
+	}
 	err := testEnv.Stop()
 	Expect(err).ToNot(HaveOccurred())
 })
+
+// testNamespace holds the objects of the controller tests. Each test names its
+// objects apart, as they share the test environment.
+const testNamespace = "default"
+
+// createNetworkGlobal creates a NetworkGlobal whose ID is its name.
+func createNetworkGlobal(name string, addressSpaces ...string) *netGlo.NetworkGlobal {
+	nGlobal := &netGlo.NetworkGlobal{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec:       netGlo.NetworkGlobalSpec{ID: name, AddressSpaces: addressSpaces},
+	}
+	ExpectWithOffset(1, k8sClient.Create(context.Background(), nGlobal)).To(Succeed())
+	return nGlobal
+}
+
+// createSubnet creates a Subnet of the NetworkGlobal in the test namespace.
+func createSubnet(name, networkGlobalID, parent, cidr string) *corev1.Subnet {
+	subnet := &corev1.Subnet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: corev1.SubnetSpec{
+			CIDR:            cidr,
+			NetworkGlobalID: networkGlobalID,
+			SubnetParentID:  parent,
+		},
+	}
+	if ipNet, err := ipam.ParseCIDR(cidr); err == nil {
+		subnet.Spec.Type = ipam.Family(ipNet)
+	}
+	ExpectWithOffset(1, k8sClient.Create(context.Background(), subnet)).To(Succeed())
+	return subnet
+}
+
+// getSubnet returns the current state of the Subnet, or an empty one if it can't be read.
+func getSubnet(name string) *corev1.Subnet {
+	subnet := &corev1.Subnet{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: name, Namespace: testNamespace}, subnet); err != nil {
+		return &corev1.Subnet{}
+	}
+	return subnet
+}
+
+// subnetState polls the state of the Subnet.
+func subnetState(name string) func() corev1.SubnetState {
+	return func() corev1.SubnetState { return getSubnet(name).Status.State }
+}
+
+// subnetExists polls whether the Subnet is still around.
+func subnetExists(name string) func() bool {
+	return func() bool {
+		err := k8sClient.Get(context.Background(), client.ObjectKey{Name: name, Namespace: testNamespace}, &corev1.Subnet{})
+		return err == nil
+	}
+}
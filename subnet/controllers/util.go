@@ -4,81 +4,63 @@ import (
 	"context"
 	"errors"
 
-	netGlo "gardener/networkGlobal/api/v1"
 	corev1 "gardener/subnet/api/v1"
 	v1 "gardener/subnet/api/v1"
 
+	corev1api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// addSubnetFinalizer adds the finalizer on the Subnet
-func (r SubnetReconciler) addSubnetFinalizer() error {
-	ctx := context.Background()
-	subnet := &v1.Subnet{}
-
-	err := r.Get(ctx, r.Request.NamespacedName, subnet)
-	if err != nil {
-		return client.IgnoreNotFound(err)
+// addSubnetFinalizer adds the finalizer on the Subnet, replacing the
+// deprecated one.
+func (r *SubnetReconciler) addSubnetFinalizer(ctx context.Context, subnet *v1.Subnet) error {
+	finalizers := sets.NewString(subnet.Finalizers...)
+	if finalizers.Has(DeprecatedSubnetFinalizerName) {
+		r.Log.Info("Renaming finalizer", "Subnet", subnet.Name)
+		finalizers.Delete(DeprecatedSubnetFinalizerName)
+		finalizers.Insert(SubnetFinalizerName)
+		return r.updateSubnetFinalizers(ctx, subnet, finalizers.List())
 	}
-
-	clone := subnet.DeepCopy()
-
-	if finalizers := sets.NewString(clone.Finalizers...); !finalizers.Has(SubnetFinalizerName) {
-		r.Log.Info("Adding finalizer")
+	if !finalizers.Has(SubnetFinalizerName) {
+		r.Log.Info("Adding finalizer", "Subnet", subnet.Name)
 		finalizers.Insert(SubnetFinalizerName)
-		err := r.updateSubnetFinalizers(finalizers.List())
-		if err != nil {
-			return client.IgnoreNotFound(err)
+		if err := r.updateSubnetFinalizers(ctx, subnet, finalizers.List()); err != nil {
+			return err
 		}
+		r.Recorder.Event(subnet, corev1api.EventTypeNormal, "Created", "Subnet is managed by the subnet controller")
 	}
 	return nil
 }
 
 // updateSubnetFinalizers updates the finalizer on the Subnet.
-func (r *SubnetReconciler) updateSubnetFinalizers(finalizers []string) error {
-	ctx := context.Background()
-	subnet := &v1.Subnet{}
-	err := r.Get(ctx, r.Request.NamespacedName, subnet)
-	if err != nil {
-		return client.IgnoreNotFound(err)
-	}
-
+func (r *SubnetReconciler) updateSubnetFinalizers(ctx context.Context, subnet *v1.Subnet, finalizers []string) error {
 	clone := subnet.DeepCopy()
 	clone.Finalizers = finalizers
 
-	err = r.Patch(ctx, clone, client.MergeFrom(subnet))
-	if err != nil {
+	if err := r.Patch(ctx, clone, client.MergeFrom(subnet)); err != nil {
 		return client.IgnoreNotFound(err)
 	}
 
-	r.Subnet = clone
+	*subnet = *clone
 	return nil
 }
 
-// deleteSubnetFinalizers deletes the finalizer from the Subnet.
-func (r *SubnetReconciler) deleteSubnetFinalizers() error {
-	ctx := context.Background()
-	subnet := &v1.Subnet{}
-	err := r.Get(ctx, r.Request.NamespacedName, subnet)
-	if err != nil {
-		return client.IgnoreNotFound(err)
-	}
-
-	clone := subnet.DeepCopy()
-
-	if finalizers := sets.NewString(clone.Finalizers...); finalizers.Has(SubnetFinalizerName) {
-		finalizers.Delete(SubnetFinalizerName)
-		err := r.updateSubnetFinalizers(finalizers.List())
-		if err != nil {
-			return client.IgnoreNotFound(err)
+// deleteSubnetFinalizers deletes the finalizer, or the deprecated one, from the Subnet.
+func (r *SubnetReconciler) deleteSubnetFinalizers(ctx context.Context, subnet *v1.Subnet) error {
+	if finalizers := sets.NewString(subnet.Finalizers...); finalizers.HasAny(SubnetFinalizerName, DeprecatedSubnetFinalizerName) {
+		finalizers.Delete(SubnetFinalizerName, DeprecatedSubnetFinalizerName)
+		if err := r.updateSubnetFinalizers(ctx, subnet, finalizers.List()); err != nil {
+			return err
 		}
+		r.Recorder.Event(subnet, corev1api.EventTypeNormal, "Deleted", "Released the finalizer, the subnet is going away")
 	}
 	return nil
 }
 
+// IsNetworkGlobalIDValid reports whether the NetworkGlobal referenced by the
+// Subnet exists. Errors other than NotFound are returned.
 func (r *SubnetReconciler) IsNetworkGlobalIDValid(obj metav1.Object) (bool, error) {
 	ctx := context.Background()
 	subnet, ok := obj.(*corev1.Subnet)
@@ -88,11 +70,11 @@ func (r *SubnetReconciler) IsNetworkGlobalIDValid(obj metav1.Object) (bool, erro
 	}
 
 	netGloID := subnet.Spec.NetworkGlobalID
-	netGlobalObject := &netGlo.NetworkGlobal{}
-
-	if err := r.Get(ctx, types.NamespacedName{Name: netGloID, Namespace: subnet.Namespace}, netGlobalObject); err != nil {
-		return false, errors.New("not valid because resource with networkGlobalID doesn't exist")
+	if netGloID == "" {
+		return false, nil
+	}
+	if _, err := getNetworkGlobal(ctx, r, subnet.Namespace, netGloID); err != nil {
+		return false, client.IgnoreNotFound(err)
 	}
-	r.Log.Info("Succesfully got the NetWorkGlobal Object", "Name", netGlobalObject.Name)
 	return true, nil
 }
@@ -7,10 +7,16 @@ require (
 	github.com/go-logr/logr v0.1.0
 	github.com/onsi/ginkgo v1.11.0
 	github.com/onsi/gomega v1.8.1
-	k8s.io/api v0.17.2 // indirect
+	github.com/prometheus/client_golang v1.0.0
+	k8s.io/api v0.17.2
 	k8s.io/apimachinery v0.17.2
 	k8s.io/client-go v0.17.2
 	sigs.k8s.io/controller-runtime v0.5.0
 )
 
-replace gardener/networkGlobal => github.com/onmetal/network-basics/networkGlobal v0.0.0-20210317165632-fd7bef291c78
+// The manager runs the NetworkGlobal controller and webhook as well, so the
+// networkGlobal API and controllers are built from the sibling directory
+// instead of a pinned commit and evolve together with the Subnet ones. The
+// image build only sees this directory and uses the vendored copy, which
+// `make vendor` refreshes and `make test` checks against the sibling directory.
+replace gardener/networkGlobal => ../networkGlobal
@@ -0,0 +1,441 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ipam contains the address arithmetic used by the subnet controller.
+package ipam
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+)
+
+// ParseCIDR parses s and returns the network it denotes.
+func ParseCIDR(s string) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %v", s, err)
+	}
+	if ip4 := ipNet.IP.To4(); ip4 != nil && len(ipNet.Mask) == net.IPv4len {
+		ipNet.IP = ip4
+	}
+	return ipNet, nil
+}
+
+// Family returns "IPv4" or "IPv6" depending on the address family of n.
+func Family(n *net.IPNet) string {
+	if _, bits := n.Mask.Size(); bits == 8*net.IPv4len {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// AddressFamily returns "IPv4" or "IPv6" depending on the address family of ip.
+func AddressFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// Set is a set of addresses of one family, kept as sorted, disjoint ranges.
+type Set struct {
+	bits   int
+	ranges []span
+}
+
+// span is an inclusive range of addresses.
+type span struct {
+	first *big.Int
+	last  *big.Int
+}
+
+// NewSet returns an empty set for addresses of the given bit length.
+func NewSet(bits int) *Set {
+	return &Set{bits: bits}
+}
+
+// All returns every address of n.
+func All(n *net.IPNet) *Set {
+	_, bits := n.Mask.Size()
+	first, last := prefixRange(n)
+	return &Set{bits: bits, ranges: []span{{first: first, last: last}}}
+}
+
+// Usable returns the addresses of n that can be handed out to hosts.
+// For IPv4 prefixes shorter than /31 the network and broadcast addresses
+// are left out, for IPv6 prefixes shorter than /127 the subnet-router anycast
+// address.
+func Usable(n *net.IPNet) *Set {
+	ones, bits := n.Mask.Size()
+	first, last := prefixRange(n)
+	switch {
+	case bits == 8*net.IPv4len && ones <= 30:
+		first.Add(first, big.NewInt(1))
+		last.Sub(last, big.NewInt(1))
+	case bits == 8*net.IPv6len && ones <= 126:
+		first.Add(first, big.NewInt(1))
+	}
+	return &Set{bits: bits, ranges: []span{{first: first, last: last}}}
+}
+
+// DefaultGateway returns the first usable host of n, or nil for IPv4 /31 and
+// /32 and IPv6 /127 and /128 prefixes, which have no address to spare.
+func DefaultGateway(n *net.IPNet) net.IP {
+	usable := Usable(n)
+	if usable.Size().Cmp(All(n).Size()) == 0 {
+		return nil
+	}
+	return usable.First()
+}
+
+// ParseRange parses the inclusive range from start to end. An empty end stands
+// for start alone.
+func ParseRange(start, end string) (net.IP, net.IP, error) {
+	first := net.ParseIP(start)
+	if first == nil {
+		return nil, nil, fmt.Errorf("invalid address %q", start)
+	}
+	if end == "" {
+		return first, first, nil
+	}
+	last := net.ParseIP(end)
+	if last == nil {
+		return nil, nil, fmt.Errorf("invalid address %q", end)
+	}
+	if (first.To4() == nil) != (last.To4() == nil) {
+		return nil, nil, fmt.Errorf("%s and %s are of different address families", first, last)
+	}
+	bits := 8 * net.IPv6len
+	if first.To4() != nil {
+		bits = 8 * net.IPv4len
+	}
+	if toInt(first, bits).Cmp(toInt(last, bits)) > 0 {
+		return nil, nil, fmt.Errorf("%s comes after %s", first, last)
+	}
+	return first, last, nil
+}
+
+// RemovePrefix removes every address of n from the set.
+func (s *Set) RemovePrefix(n *net.IPNet) {
+	if _, bits := n.Mask.Size(); bits != s.bits {
+		return
+	}
+	first, last := prefixRange(n)
+	s.remove(first, last)
+}
+
+// RemoveRange removes the addresses from first to last from the set.
+func (s *Set) RemoveRange(first, last net.IP) {
+	if !s.sameFamily(first) || !s.sameFamily(last) {
+		return
+	}
+	s.remove(toInt(first, s.bits), toInt(last, s.bits))
+}
+
+// RemoveIP removes ip from the set.
+func (s *Set) RemoveIP(ip net.IP) {
+	if !s.sameFamily(ip) {
+		return
+	}
+	i := toInt(ip, s.bits)
+	s.remove(i, i)
+}
+
+// Has reports whether ip is part of the set.
+func (s *Set) Has(ip net.IP) bool {
+	if !s.sameFamily(ip) {
+		return false
+	}
+	i := toInt(ip, s.bits)
+	for _, r := range s.ranges {
+		if i.Cmp(r.first) >= 0 && i.Cmp(r.last) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// First returns the lowest address of the set, or nil if it is empty.
+func (s *Set) First() net.IP {
+	if len(s.ranges) == 0 {
+		return nil
+	}
+	return fromInt(s.ranges[0].first, s.bits)
+}
+
+// FirstBlock returns the lowest prefix of the given length that lies
+// entirely within the set, or nil if there is none.
+func (s *Set) FirstBlock(ones int) *net.IPNet {
+	if ones < 0 || ones > s.bits {
+		return nil
+	}
+	size := new(big.Int).Lsh(big.NewInt(1), uint(s.bits-ones))
+	for _, r := range s.ranges {
+		// Round the start of the range up to the next multiple of the block size.
+		start := new(big.Int).Add(r.first, size)
+		start.Sub(start, big.NewInt(1))
+		start.Div(start, size)
+		start.Mul(start, size)
+
+		last := new(big.Int).Add(start, size)
+		last.Sub(last, big.NewInt(1))
+		if last.Cmp(r.last) <= 0 {
+			return &net.IPNet{IP: fromInt(start, s.bits), Mask: net.CIDRMask(ones, s.bits)}
+		}
+	}
+	return nil
+}
+
+// Size returns the number of addresses in the set.
+func (s *Set) Size() *big.Int {
+	size := new(big.Int)
+	for _, r := range s.ranges {
+		size.Add(size, r.len())
+	}
+	return size
+}
+
+// Range is an inclusive range of addresses.
+type Range struct {
+	First net.IP
+	Last  net.IP
+}
+
+// Ranges returns the set as disjoint ranges in address order.
+func (s *Set) Ranges() []Range {
+	var ranges []Range
+	for _, r := range s.ranges {
+		ranges = append(ranges, Range{First: fromInt(r.first, s.bits), Last: fromInt(r.last, s.bits)})
+	}
+	return ranges
+}
+
+// Blocks returns the set as the fewest aligned prefixes that cover it exactly,
+// in address order.
+func (s *Set) Blocks() []*net.IPNet {
+	var blocks []*net.IPNet
+	for _, r := range s.ranges {
+		first := new(big.Int).Set(r.first)
+		for first.Cmp(r.last) <= 0 {
+			// The block may not be larger than the alignment of its first
+			// address, nor reach past the end of the range.
+			hostBits := s.bits
+			if first.Sign() != 0 {
+				hostBits = int(first.TrailingZeroBits())
+			}
+			remaining := new(big.Int).Sub(r.last, first)
+			remaining.Add(remaining, big.NewInt(1))
+			if l := remaining.BitLen() - 1; l < hostBits {
+				hostBits = l
+			}
+			blocks = append(blocks, &net.IPNet{IP: fromInt(first, s.bits), Mask: net.CIDRMask(s.bits-hostBits, s.bits)})
+			first.Add(first, new(big.Int).Lsh(big.NewInt(1), uint(hostBits)))
+		}
+	}
+	return blocks
+}
+
+// LargestBlocks returns at most n of the blocks of the set, largest first and
+// in address order among blocks of the same size.
+func (s *Set) LargestBlocks(n int) []*net.IPNet {
+	blocks := s.Blocks()
+	sort.SliceStable(blocks, func(i, j int) bool {
+		iOnes, _ := blocks[i].Mask.Size()
+		jOnes, _ := blocks[j].Mask.Size()
+		return iOnes < jOnes
+	})
+	if len(blocks) > n {
+		blocks = blocks[:n]
+	}
+	return blocks
+}
+
+func (s *Set) sameFamily(ip net.IP) bool {
+	if s.bits == 8*net.IPv4len {
+		return ip.To4() != nil
+	}
+	return ip.To4() == nil && ip.To16() != nil
+}
+
+func (s *Set) remove(first, last *big.Int) {
+	var ranges []span
+	for _, r := range s.ranges {
+		if last.Cmp(r.first) < 0 || first.Cmp(r.last) > 0 {
+			ranges = append(ranges, r)
+			continue
+		}
+		if first.Cmp(r.first) > 0 {
+			ranges = append(ranges, span{first: r.first, last: new(big.Int).Sub(first, big.NewInt(1))})
+		}
+		if last.Cmp(r.last) < 0 {
+			ranges = append(ranges, span{first: new(big.Int).Add(last, big.NewInt(1)), last: r.last})
+		}
+	}
+	s.ranges = ranges
+}
+
+func (r span) len() *big.Int {
+	l := new(big.Int).Sub(r.last, r.first)
+	return l.Add(l, big.NewInt(1))
+}
+
+// prefixRange returns the first and last address of n.
+func prefixRange(n *net.IPNet) (*big.Int, *big.Int) {
+	ones, bits := n.Mask.Size()
+	first := toInt(n.IP.Mask(n.Mask), bits)
+	last := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	last.Sub(last, big.NewInt(1))
+	last.Or(last, first)
+	return first, last
+}
+
+// toInt returns ip as an integer, reading it as an address of the given
+// bit length.
+func toInt(ip net.IP, bits int) *big.Int {
+	if bits == 8*net.IPv4len {
+		ip = ip.To4()
+	} else {
+		ip = ip.To16()
+	}
+	return new(big.Int).SetBytes(ip)
+}
+
+// fromInt returns i as an address of the given bit length.
+func fromInt(i *big.Int, bits int) net.IP {
+	ip := make(net.IP, bits/8)
+	b := i.Bytes()
+	copy(ip[len(ip)-len(b):], b)
+	return ip
+}
+
+// Contains reports whether child lies entirely within parent.
+func Contains(parent, child *net.IPNet) bool {
+	parentOnes, parentBits := parent.Mask.Size()
+	childOnes, childBits := child.Mask.Size()
+	return parentBits == childBits && childOnes >= parentOnes && parent.Contains(child.IP)
+}
+
+// ContainedInAny reports whether n lies within one of the prefixes. Prefixes
+// that don't parse are skipped.
+func ContainedInAny(prefixes []string, n *net.IPNet) bool {
+	for _, prefix := range prefixes {
+		if p, err := ParseCIDR(prefix); err == nil && Contains(p, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// RangeContains reports whether ip lies in the range from first to last.
+func RangeContains(first, last, ip net.IP) bool {
+	if AddressFamily(first) != AddressFamily(ip) {
+		return false
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		bits = 8 * net.IPv4len
+	}
+	i := toInt(ip, bits)
+	return i.Cmp(toInt(first, bits)) >= 0 && i.Cmp(toInt(last, bits)) <= 0
+}
+
+// OverlapsRange reports whether n shares at least one address with the range
+// from first to last.
+func OverlapsRange(n *net.IPNet, first, last net.IP) bool {
+	_, bits := n.Mask.Size()
+	set := All(n)
+	if !set.sameFamily(first) || !set.sameFamily(last) {
+		return false
+	}
+	nFirst, nLast := prefixRange(n)
+	return toInt(first, bits).Cmp(nLast) <= 0 && toInt(last, bits).Cmp(nFirst) >= 0
+}
+
+// Overlaps reports whether a and b share at least one address.
+func Overlaps(a, b *net.IPNet) bool {
+	_, aBits := a.Mask.Size()
+	_, bBits := b.Mask.Size()
+	return aBits == bBits && (a.Contains(b.IP) || b.Contains(a.IP))
+}
+
+// FormatSize returns size in a form people can read. IPv4 sizes and small
+// IPv6 sizes are written out, IPv6 sizes that are a power of two as 2^k, sizes
+// just below one as 2^k-n and other large IPv6 sizes as the number of /64
+// networks they make up, with a leading ~ if there is a remainder.
+func FormatSize(size *big.Int, family string) string {
+	if family != "IPv6" || size.BitLen() <= 32 {
+		return size.String()
+	}
+	if size.TrailingZeroBits() == uint(size.BitLen()-1) {
+		return fmt.Sprintf("2^%d", size.BitLen()-1)
+	}
+	// Sizes a few addresses short of a power of two, like a /64 without its
+	// subnet-router anycast address.
+	if short := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(size.BitLen())), size); short.BitLen() <= 16 {
+		return fmt.Sprintf("2^%d-%s", size.BitLen(), short)
+	}
+	if size.BitLen() > 64 {
+		networks, rest := new(big.Int).QuoRem(size, new(big.Int).Lsh(big.NewInt(1), 64), new(big.Int))
+		if rest.Sign() == 0 {
+			return fmt.Sprintf("%s /64s", networks)
+		}
+		return fmt.Sprintf("~%s /64s", networks)
+	}
+	return size.String()
+}
+
+// SummarizeBlocks describes the free blocks of a set in a few words, e.g.
+// "largest free block /25, 3 free /26s". Besides the largest block it counts
+// the blocks of the next few prefix lengths, at most sizes of them.
+func SummarizeBlocks(blocks []*net.IPNet, sizes int) string {
+	if len(blocks) == 0 {
+		return "no free block"
+	}
+
+	counts := map[int]int{}
+	var lengths []int
+	for _, block := range blocks {
+		ones, _ := block.Mask.Size()
+		if counts[ones] == 0 {
+			lengths = append(lengths, ones)
+		}
+		counts[ones]++
+	}
+	sort.Ints(lengths)
+
+	var parts strings.Builder
+	fmt.Fprintf(&parts, "largest free block /%d", lengths[0])
+	if counts[lengths[0]] > 1 {
+		fmt.Fprintf(&parts, ", %s", countBlocks(counts[lengths[0]], lengths[0]))
+	}
+	for i, ones := range lengths[1:] {
+		if i == sizes {
+			break
+		}
+		fmt.Fprintf(&parts, ", %s", countBlocks(counts[ones], ones))
+	}
+	return parts.String()
+}
+
+func countBlocks(count, ones int) string {
+	if count == 1 {
+		return fmt.Sprintf("1 free /%d", ones)
+	}
+	return fmt.Sprintf("%d free /%ds", count, ones)
+}
@@ -0,0 +1,235 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func mustParseCIDR(s string) *net.IPNet {
+	n, err := ParseCIDR(s)
+	Expect(err).NotTo(HaveOccurred())
+	return n
+}
+
+var _ = Describe("Set", func() {
+	It("leaves out network and broadcast addresses for IPv4", func() {
+		Expect(Usable(mustParseCIDR("10.12.34.0/24")).Size().Int64()).To(Equal(int64(254)))
+		Expect(Usable(mustParseCIDR("10.12.34.0/31")).Size().Int64()).To(Equal(int64(2)))
+		Expect(Usable(mustParseCIDR("10.12.34.1/32")).Size().Int64()).To(Equal(int64(1)))
+	})
+
+	It("leaves out the subnet-router anycast address for IPv6", func() {
+		Expect(Usable(mustParseCIDR("2001:db8::/120")).Size().Int64()).To(Equal(int64(255)))
+		Expect(Usable(mustParseCIDR("2001:db8::/120")).Has(net.ParseIP("2001:db8::"))).To(BeFalse())
+		Expect(Usable(mustParseCIDR("2001:db8::/127")).Size().Int64()).To(Equal(int64(2)))
+	})
+
+	It("picks the first usable host as default gateway", func() {
+		Expect(DefaultGateway(mustParseCIDR("10.12.34.0/24")).String()).To(Equal("10.12.34.1"))
+		Expect(DefaultGateway(mustParseCIDR("2001:db8::/64")).String()).To(Equal("2001:db8::1"))
+		Expect(DefaultGateway(mustParseCIDR("10.12.34.0/31"))).To(BeNil())
+		Expect(DefaultGateway(mustParseCIDR("2001:db8::1/128"))).To(BeNil())
+	})
+
+	It("removes address ranges", func() {
+		s := Usable(mustParseCIDR("10.12.34.0/24"))
+		first, last, err := ParseRange("10.12.34.200", "10.12.34.250")
+		Expect(err).NotTo(HaveOccurred())
+		s.RemoveRange(first, last)
+		Expect(s.Size().Int64()).To(Equal(int64(254 - 51)))
+		Expect(s.Has(net.ParseIP("10.12.34.199"))).To(BeTrue())
+		Expect(s.Has(net.ParseIP("10.12.34.200"))).To(BeFalse())
+		Expect(s.Has(net.ParseIP("10.12.34.251"))).To(BeTrue())
+
+		first, last, err = ParseRange("2001:db8::5", "")
+		Expect(err).NotTo(HaveOccurred())
+		s.RemoveRange(first, last)
+		Expect(s.Size().Int64()).To(Equal(int64(254 - 51)))
+	})
+
+	It("rejects malformed ranges", func() {
+		_, _, err := ParseRange("10.12.34.250", "10.12.34.200")
+		Expect(err).To(HaveOccurred())
+		_, _, err = ParseRange("10.12.34.1", "2001:db8::1")
+		Expect(err).To(HaveOccurred())
+		_, _, err = ParseRange("10.12.34", "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("removes child prefixes", func() {
+		s := Usable(mustParseCIDR("10.12.34.0/24"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.0/25"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.192/27"))
+		Expect(s.Size().Int64()).To(Equal(int64(254 - 127 - 32)))
+	})
+
+	It("hands out the lowest free address", func() {
+		s := Usable(mustParseCIDR("10.12.34.0/24"))
+		Expect(s.First().String()).To(Equal("10.12.34.1"))
+		s.RemoveIP(net.ParseIP("10.12.34.1"))
+		Expect(s.Has(net.ParseIP("10.12.34.1"))).To(BeFalse())
+		Expect(s.First().String()).To(Equal("10.12.34.2"))
+
+		v6 := Usable(mustParseCIDR("2001:db8::/126"))
+		v6.RemoveIP(net.ParseIP("2001:db8::"))
+		Expect(v6.First().String()).To(Equal("2001:db8::1"))
+		Expect(v6.Has(net.ParseIP("10.12.34.1"))).To(BeFalse())
+	})
+
+	It("finds the first aligned free block", func() {
+		s := All(mustParseCIDR("10.12.34.0/24"))
+		Expect(s.FirstBlock(26).String()).To(Equal("10.12.34.0/26"))
+
+		s.RemovePrefix(mustParseCIDR("10.12.34.0/27"))
+		s.RemoveIP(net.ParseIP("10.12.34.70"))
+		Expect(s.FirstBlock(26).String()).To(Equal("10.12.34.128/26"))
+		Expect(s.FirstBlock(27).String()).To(Equal("10.12.34.32/27"))
+		Expect(s.FirstBlock(24)).To(BeNil())
+
+		v6 := All(mustParseCIDR("2001:db8::/48"))
+		v6.RemovePrefix(mustParseCIDR("2001:db8::/64"))
+		Expect(v6.FirstBlock(64).String()).To(Equal("2001:db8:0:1::/64"))
+	})
+
+	It("lists the ranges of the set", func() {
+		s := Usable(mustParseCIDR("10.12.34.0/24"))
+		s.RemoveIP(net.ParseIP("10.12.34.5"))
+		Expect(s.Ranges()).To(HaveLen(2))
+		Expect(s.Ranges()[0].First.String()).To(Equal("10.12.34.1"))
+		Expect(s.Ranges()[0].Last.String()).To(Equal("10.12.34.4"))
+		Expect(s.Ranges()[1].First.String()).To(Equal("10.12.34.6"))
+		Expect(s.Ranges()[1].Last.String()).To(Equal("10.12.34.254"))
+	})
+
+	It("splits the set into aligned blocks", func() {
+		s := All(mustParseCIDR("10.12.34.0/24"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.0/26"))
+		s.RemoveIP(net.ParseIP("10.12.34.64"))
+		Expect(fmt.Sprint(s.Blocks())).To(Equal("[10.12.34.65/32 10.12.34.66/31 10.12.34.68/30 10.12.34.72/29 " +
+			"10.12.34.80/28 10.12.34.96/27 10.12.34.128/25]"))
+		Expect(fmt.Sprint(s.LargestBlocks(3))).To(Equal("[10.12.34.128/25 10.12.34.96/27 10.12.34.80/28]"))
+
+		v6 := All(mustParseCIDR("::/0"))
+		Expect(fmt.Sprint(v6.Blocks())).To(Equal("[::/0]"))
+		Expect(NewSet(32).Blocks()).To(BeEmpty())
+	})
+
+	It("ignores prefixes of the other family", func() {
+		s := Usable(mustParseCIDR("10.12.34.0/24"))
+		s.RemovePrefix(mustParseCIDR("2001:db8::/64"))
+		Expect(s.Size().Int64()).To(Equal(int64(254)))
+	})
+
+	It("rejects malformed CIDRs", func() {
+		_, err := ParseCIDR("10.12.34.0/33")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Prefix relations", func() {
+	It("detects containment", func() {
+		parent := mustParseCIDR("10.12.0.0/16")
+		Expect(Contains(parent, mustParseCIDR("10.12.34.0/24"))).To(BeTrue())
+		Expect(Contains(parent, parent)).To(BeTrue())
+		Expect(Contains(parent, mustParseCIDR("10.0.0.0/8"))).To(BeFalse())
+		Expect(Contains(parent, mustParseCIDR("10.13.0.0/24"))).To(BeFalse())
+		Expect(Contains(mustParseCIDR("::/0"), mustParseCIDR("10.12.34.0/24"))).To(BeFalse())
+	})
+
+	It("detects containment in any of several prefixes", func() {
+		spaces := []string{"192.168.0.0/16", "10.0.0.0/8", "2001:db8::/32"}
+		Expect(ContainedInAny(spaces, mustParseCIDR("10.12.34.0/24"))).To(BeTrue())
+		Expect(ContainedInAny(spaces, mustParseCIDR("2001:db8:1::/48"))).To(BeTrue())
+		Expect(ContainedInAny(spaces, mustParseCIDR("172.16.0.0/24"))).To(BeFalse())
+		Expect(ContainedInAny(nil, mustParseCIDR("10.12.34.0/24"))).To(BeFalse())
+	})
+
+	It("detects addresses in ranges", func() {
+		first, last, _ := ParseRange("10.12.34.200", "10.12.34.250")
+		Expect(RangeContains(first, last, net.ParseIP("10.12.34.200"))).To(BeTrue())
+		Expect(RangeContains(first, last, net.ParseIP("10.12.34.251"))).To(BeFalse())
+		Expect(RangeContains(first, last, net.ParseIP("2001:db8::1"))).To(BeFalse())
+	})
+
+	It("detects overlaps with address ranges", func() {
+		first, last, _ := ParseRange("10.12.34.200", "10.12.34.250")
+		Expect(OverlapsRange(mustParseCIDR("10.12.34.192/27"), first, last)).To(BeTrue())
+		Expect(OverlapsRange(mustParseCIDR("10.12.34.0/24"), first, last)).To(BeTrue())
+		Expect(OverlapsRange(mustParseCIDR("10.12.34.252/30"), first, last)).To(BeFalse())
+		Expect(OverlapsRange(mustParseCIDR("2001:db8::/64"), first, last)).To(BeFalse())
+	})
+
+	It("detects overlaps", func() {
+		Expect(Overlaps(mustParseCIDR("10.12.34.0/24"), mustParseCIDR("10.12.34.128/25"))).To(BeTrue())
+		Expect(Overlaps(mustParseCIDR("10.12.34.128/25"), mustParseCIDR("10.12.34.0/24"))).To(BeTrue())
+		Expect(Overlaps(mustParseCIDR("10.12.34.0/25"), mustParseCIDR("10.12.34.128/25"))).To(BeFalse())
+	})
+})
+
+var _ = Describe("SummarizeBlocks", func() {
+	It("names the largest block and counts the next sizes", func() {
+		s := All(mustParseCIDR("10.12.34.0/24"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.0/26"))
+		s.RemoveIP(net.ParseIP("10.12.34.64"))
+		Expect(SummarizeBlocks(s.Blocks(), 2)).To(Equal("largest free block /25, 1 free /27, 1 free /28"))
+
+		s = All(mustParseCIDR("10.12.34.0/24"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.64/26"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.128/27"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.192/26"))
+		Expect(SummarizeBlocks(s.Blocks(), 2)).To(Equal("largest free block /26, 1 free /27"))
+
+		s = All(mustParseCIDR("10.12.34.0/24"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.64/26"))
+		s.RemovePrefix(mustParseCIDR("10.12.34.192/26"))
+		Expect(SummarizeBlocks(s.Blocks(), 2)).To(Equal("largest free block /26, 2 free /26s"))
+	})
+
+	It("reports a full set", func() {
+		Expect(SummarizeBlocks(nil, 2)).To(Equal("no free block"))
+	})
+})
+
+var _ = Describe("FormatSize", func() {
+	It("writes out IPv4 and small IPv6 sizes", func() {
+		Expect(FormatSize(Usable(mustParseCIDR("10.0.0.0/8")).Size(), "IPv4")).To(Equal("16777214"))
+		Expect(FormatSize(All(mustParseCIDR("2001:db8::/120")).Size(), "IPv6")).To(Equal("256"))
+	})
+
+	It("writes large IPv6 powers of two as exponents", func() {
+		Expect(FormatSize(All(mustParseCIDR("2001:db8::/64")).Size(), "IPv6")).To(Equal("2^64"))
+		Expect(FormatSize(All(mustParseCIDR("2001:db8::/32")).Size(), "IPv6")).To(Equal("2^96"))
+	})
+
+	It("writes IPv6 sizes just below a power of two as a difference", func() {
+		Expect(FormatSize(Usable(mustParseCIDR("2001:db8::/64")).Size(), "IPv6")).To(Equal("2^64-1"))
+	})
+
+	It("counts the /64 networks of other large IPv6 sizes", func() {
+		free := All(mustParseCIDR("2001:db8::/48"))
+		free.RemovePrefix(mustParseCIDR("2001:db8::/64"))
+		Expect(FormatSize(free.Size(), "IPv6")).To(Equal("65535 /64s"))
+
+		free.RemoveIP(net.ParseIP("2001:db8:0:1::1"))
+		Expect(FormatSize(free.Size(), "IPv6")).To(Equal("~65534 /64s"))
+	})
+})
@@ -0,0 +1,206 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kea renders the subnet4 and subnet6 lists of the Kea DHCP servers.
+// The lists are meant to be included into the Dhcp4 and Dhcp6 configurations,
+// which keep the interfaces, lease databases and other server settings.
+package kea
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gardener/subnet/ipam"
+)
+
+// Network holds what the DHCP servers need to know about one Subnet.
+type Network struct {
+	// Name is the name of the Subnet. The Kea subnet id is derived from it.
+	Name string
+	CIDR *net.IPNet
+
+	// Pools are the ranges handed out to any client.
+	Pools        []ipam.Range
+	Reservations []Reservation
+
+	Gateway       net.IP
+	DNSServers    []net.IP
+	SearchDomains []string
+	MTU           int
+	VLANID        int
+}
+
+// Reservation ties an address to the hardware address of a client.
+type Reservation struct {
+	HWAddress string
+	Address   net.IP
+}
+
+// Subnet is an entry of the subnet4 or subnet6 list.
+type Subnet struct {
+	ID           uint32             `json:"id"`
+	Subnet       string             `json:"subnet"`
+	Pools        []Pool             `json:"pools,omitempty"`
+	OptionData   []OptionData       `json:"option-data,omitempty"`
+	Reservations []ReservationEntry `json:"reservations,omitempty"`
+	UserContext  UserContext        `json:"user-context"`
+}
+
+// Pool is a range of addresses written as "first - last".
+type Pool struct {
+	Pool string `json:"pool"`
+}
+
+// OptionData sets a DHCP option of the subnet.
+type OptionData struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// ReservationEntry is a host reservation. DHCPv4 reservations hold a single
+// address, DHCPv6 reservations a list.
+type ReservationEntry struct {
+	HWAddress   string   `json:"hw-address"`
+	IPAddress   string   `json:"ip-address,omitempty"`
+	IPAddresses []string `json:"ip-addresses,omitempty"`
+}
+
+// UserContext carries the origin of a subnet, which Kea passes on to hooks
+// and shows in its API.
+type UserContext struct {
+	Subnet string `json:"subnet"`
+	VLANID int    `json:"vlan-id,omitempty"`
+}
+
+// Subnet4 returns the subnet4 list of the IPv4 networks.
+func Subnet4(networks []Network) []Subnet {
+	return subnets(networks, "IPv4", func(n *Network) []OptionData {
+		var options []OptionData
+		if n.Gateway != nil {
+			options = append(options, OptionData{Name: "routers", Data: n.Gateway.String()})
+		}
+		if servers := addressesOf(n.DNSServers, "IPv4"); servers != "" {
+			options = append(options, OptionData{Name: "domain-name-servers", Data: servers})
+		}
+		if len(n.SearchDomains) > 0 {
+			options = append(options, OptionData{Name: "domain-search", Data: strings.Join(n.SearchDomains, ", ")})
+		}
+		if n.MTU != 0 {
+			options = append(options, OptionData{Name: "interface-mtu", Data: strconv.Itoa(n.MTU)})
+		}
+		return options
+	})
+}
+
+// Subnet6 returns the subnet6 list of the IPv6 networks. Hosts learn the
+// gateway and the MTU from router advertisements, so they aren't options.
+func Subnet6(networks []Network) []Subnet {
+	return subnets(networks, "IPv6", func(n *Network) []OptionData {
+		var options []OptionData
+		if servers := addressesOf(n.DNSServers, "IPv6"); servers != "" {
+			options = append(options, OptionData{Name: "dns-servers", Data: servers})
+		}
+		if len(n.SearchDomains) > 0 {
+			options = append(options, OptionData{Name: "domain-search", Data: strings.Join(n.SearchDomains, ", ")})
+		}
+		return options
+	})
+}
+
+// Render returns the list as indented JSON.
+func Render(subnets []Subnet) (string, error) {
+	if subnets == nil {
+		subnets = []Subnet{}
+	}
+	out, err := json.MarshalIndent(subnets, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+// subnets returns the entries of the networks of the family, ordered by name
+// and with the reservations ordered by address, so the output only changes
+// with the networks.
+func subnets(networks []Network, family string, options func(*Network) []OptionData) []Subnet {
+	var matching []*Network
+	for i := range networks {
+		if ipam.Family(networks[i].CIDR) == family {
+			matching = append(matching, &networks[i])
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].Name < matching[j].Name })
+
+	var entries []Subnet
+	ids := map[uint32]bool{}
+	for _, n := range matching {
+		entry := Subnet{
+			ID:          subnetID(n.Name, ids),
+			Subnet:      n.CIDR.String(),
+			OptionData:  options(n),
+			UserContext: UserContext{Subnet: n.Name, VLANID: n.VLANID},
+		}
+		for _, pool := range n.Pools {
+			entry.Pools = append(entry.Pools, Pool{Pool: pool.First.String() + " - " + pool.Last.String()})
+		}
+		reservations := append([]Reservation(nil), n.Reservations...)
+		sort.Slice(reservations, func(i, j int) bool {
+			return bytes.Compare(reservations[i].Address.To16(), reservations[j].Address.To16()) < 0
+		})
+		for _, reservation := range reservations {
+			r := ReservationEntry{HWAddress: reservation.HWAddress}
+			if family == "IPv4" {
+				r.IPAddress = reservation.Address.String()
+			} else {
+				r.IPAddresses = []string{reservation.Address.String()}
+			}
+			entry.Reservations = append(entry.Reservations, r)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// subnetID derives the Kea subnet id from the name of the Subnet, so that it
+// stays the same as other Subnets come and go and leases remain attached to
+// it. Should two names hash to the same id, the later one takes the next free
+// id. Kea doesn't accept 0 and 4294967295.
+func subnetID(name string, taken map[uint32]bool) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	id := h.Sum32()
+	for id == 0 || id == ^uint32(0) || taken[id] {
+		id++
+	}
+	taken[id] = true
+	return id
+}
+
+// addressesOf returns the addresses of the family as a comma separated list.
+func addressesOf(ips []net.IP, family string) string {
+	var addresses []string
+	for _, ip := range ips {
+		if ipam.AddressFamily(ip) == family {
+			addresses = append(addresses, ip.String())
+		}
+	}
+	return strings.Join(addresses, ", ")
+}
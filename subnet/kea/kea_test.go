@@ -0,0 +1,106 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kea
+
+import (
+	"net"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"gardener/subnet/ipam"
+)
+
+func mustParseCIDR(s string) *net.IPNet {
+	n, err := ipam.ParseCIDR(s)
+	Expect(err).NotTo(HaveOccurred())
+	return n
+}
+
+var _ = Describe("Subnet4", func() {
+	It("renders pools, options and reservations", func() {
+		subnets := Subnet4([]Network{{
+			Name:          "rack-1-ipv4",
+			CIDR:          mustParseCIDR("10.12.34.0/24"),
+			Pools:         []ipam.Range{{First: net.ParseIP("10.12.34.11"), Last: net.ParseIP("10.12.34.254")}},
+			Reservations:  []Reservation{{HWAddress: "0c:c4:7a:00:00:01", Address: net.ParseIP("10.12.34.20")}},
+			Gateway:       net.ParseIP("10.12.34.1"),
+			DNSServers:    []net.IP{net.ParseIP("10.12.0.53"), net.ParseIP("2001:db8::53")},
+			SearchDomains: []string{"frankfurt.example.com"},
+			MTU:           9000,
+			VLANID:        34,
+		}, {
+			Name: "rack-1-ipv6",
+			CIDR: mustParseCIDR("2001:db8:12:1::/64"),
+		}})
+
+		Expect(subnets).To(HaveLen(1))
+		out, err := Render(subnets)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(MatchJSON(`[{
+			"id": ` + idOf("rack-1-ipv4") + `,
+			"subnet": "10.12.34.0/24",
+			"pools": [{"pool": "10.12.34.11 - 10.12.34.254"}],
+			"option-data": [
+				{"name": "routers", "data": "10.12.34.1"},
+				{"name": "domain-name-servers", "data": "10.12.0.53"},
+				{"name": "domain-search", "data": "frankfurt.example.com"},
+				{"name": "interface-mtu", "data": "9000"}
+			],
+			"reservations": [{"hw-address": "0c:c4:7a:00:00:01", "ip-address": "10.12.34.20"}],
+			"user-context": {"subnet": "rack-1-ipv4", "vlan-id": 34}
+		}]`))
+	})
+
+	It("renders an empty list", func() {
+		Expect(Render(Subnet4(nil))).To(Equal("[]\n"))
+	})
+})
+
+var _ = Describe("Subnet6", func() {
+	It("renders name servers of the family and address lists", func() {
+		subnets := Subnet6([]Network{{
+			Name:         "rack-1-ipv6",
+			CIDR:         mustParseCIDR("2001:db8:12:1::/64"),
+			Reservations: []Reservation{{HWAddress: "0c:c4:7a:00:00:01", Address: net.ParseIP("2001:db8:12:1::20")}},
+			Gateway:      net.ParseIP("2001:db8:12:1::1"),
+			DNSServers:   []net.IP{net.ParseIP("10.12.0.53"), net.ParseIP("2001:db8::53")},
+			MTU:          9000,
+		}})
+
+		Expect(subnets).To(HaveLen(1))
+		Expect(subnets[0].OptionData).To(Equal([]OptionData{{Name: "dns-servers", Data: "2001:db8::53"}}))
+		Expect(subnets[0].Reservations).To(Equal([]ReservationEntry{{
+			HWAddress:   "0c:c4:7a:00:00:01",
+			IPAddresses: []string{"2001:db8:12:1::20"},
+		}}))
+	})
+})
+
+var _ = Describe("subnetID", func() {
+	It("keeps ids unique and stable", func() {
+		taken := map[uint32]bool{}
+		first := subnetID("rack-1-ipv4", taken)
+		Expect(subnetID("rack-1-ipv4", map[uint32]bool{})).To(Equal(first))
+		Expect(subnetID("rack-1-ipv4", taken)).To(Equal(first + 1))
+	})
+})
+
+func idOf(name string) string {
+	return strconv.FormatUint(uint64(subnetID(name, map[uint32]bool{})), 10)
+}
@@ -19,15 +19,19 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	netGlo "gardener/networkGlobal/api/v1"
+	netGloControllers "gardener/networkGlobal/controllers"
 	corev1 "gardener/subnet/api/v1"
+	"gardener/subnet/api/v1alpha1"
 	"gardener/subnet/controllers"
 	// +kubebuilder:scaffold:imports
 )
@@ -42,16 +46,55 @@ func init() {
 
 	_ = corev1.AddToScheme(scheme)
 	_ = netGlo.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var maxConcurrentReconciles int
+	var orphanSweepInterval time.Duration
+	var orphanGracePeriod time.Duration
+	var deleteOrphanedSubnets bool
+	var enableNetworkGlobal bool
+	var enableSubnet bool
+	var enableAddressSpace bool
+	var enablePartition bool
+	var enableIPAddressClaim bool
+	var enableDualStackSubnet bool
+	var enableKeaConfig bool
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
-			"Enabling this will ensure there is only one active controller manager.")
+			"Enabling this will ensure there is only one active controller manager. "+
+			"It is required when running more than one replica, as addresses and carved blocks are handed out under in-process locks.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", true,
+		"Serve the admission webhooks. "+
+			"Disable this when running the manager outside of the cluster without serving certificates.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of objects of each kind that are reconciled in parallel.")
+	flag.DurationVar(&orphanSweepInterval, "orphan-sweep-interval", 5*time.Minute,
+		"How often to look for subnets whose networkGlobal has disappeared.")
+	flag.DurationVar(&orphanGracePeriod, "orphan-grace-period", 10*time.Minute,
+		"How long a subnet stays orphaned before it is deleted.")
+	flag.BoolVar(&deleteOrphanedSubnets, "delete-orphaned-subnets", false,
+		"Delete orphaned subnets after the grace period instead of only flagging them.")
+	flag.BoolVar(&enableNetworkGlobal, "enable-networkglobal-controller", true,
+		"Run the NetworkGlobal controllers of both API groups and the webhook.")
+	flag.BoolVar(&enableSubnet, "enable-subnet-controller", true,
+		"Run the Subnet controllers of both API groups, the webhook and the orphan sweeper.")
+	flag.BoolVar(&enableAddressSpace, "enable-addressspace-controller", true,
+		"Run the controller reporting the address space usage of NetworkGlobals.")
+	flag.BoolVar(&enablePartition, "enable-partition-controller", true,
+		"Run the Partition controller and webhook.")
+	flag.BoolVar(&enableIPAddressClaim, "enable-ipaddressclaim-controller", true,
+		"Run the IPAddressClaim controller and webhook.")
+	flag.BoolVar(&enableDualStackSubnet, "enable-dualstacksubnet-controller", true,
+		"Run the DualStackSubnet controller and webhook.")
+	flag.BoolVar(&enableKeaConfig, "enable-keaconfig-controller", false,
+		"Render the Kea DHCP subnets of each Partition into a ConfigMap.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -68,14 +111,159 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.SubnetReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Subnet"),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Subnet")
+	// The indexes are shared by all controllers and webhooks, so they are
+	// registered no matter which of them are enabled.
+	if err = netGloControllers.SetupIndexes(mgr); err != nil {
+		setupLog.Error(err, "unable to register the field indexes")
 		os.Exit(1)
 	}
+	if err = controllers.SetupIndexes(mgr); err != nil {
+		setupLog.Error(err, "unable to register the field indexes")
+		os.Exit(1)
+	}
+	options := controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}
+
+	if enableNetworkGlobal {
+		if err = (&netGloControllers.NetworkGlobalReconciler{
+			Client:   mgr.GetClient(),
+			Recorder: mgr.GetEventRecorderFor("networkglobal-controller"),
+			Log:      ctrl.Log.WithName("controllers").WithName("NetworkGlobal"),
+			Scheme:   mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NetworkGlobal")
+			os.Exit(1)
+		}
+		if err = (&controllers.MigratedNetworkGlobalReconciler{
+			Client:   mgr.GetClient(),
+			Recorder: mgr.GetEventRecorderFor("migrated-networkglobal-controller"),
+			Log:      ctrl.Log.WithName("controllers").WithName("MigratedNetworkGlobal"),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "MigratedNetworkGlobal")
+			os.Exit(1)
+		}
+		if enableWebhooks {
+			if err = (&netGlo.NetworkGlobal{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "NetworkGlobal")
+				os.Exit(1)
+			}
+		}
+	}
+	if enableSubnet {
+		if err = (&controllers.SubnetReconciler{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Recorder:  mgr.GetEventRecorderFor("subnet-controller"),
+			Log:       ctrl.Log.WithName("controllers").WithName("Subnet"),
+			Scheme:    mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Subnet")
+			os.Exit(1)
+		}
+		if err = (&controllers.MigratedSubnetReconciler{
+			Client:   mgr.GetClient(),
+			Recorder: mgr.GetEventRecorderFor("migrated-subnet-controller"),
+			Log:      ctrl.Log.WithName("controllers").WithName("MigratedSubnet"),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "MigratedSubnet")
+			os.Exit(1)
+		}
+		if enableWebhooks {
+			if err = (&corev1.Subnet{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "Subnet")
+				os.Exit(1)
+			}
+		}
+		if err = mgr.Add(&controllers.OrphanSweeper{
+			Client:      mgr.GetClient(),
+			Log:         ctrl.Log.WithName("controllers").WithName("OrphanSweeper"),
+			Recorder:    mgr.GetEventRecorderFor("subnet-orphan-sweeper"),
+			Interval:    orphanSweepInterval,
+			GracePeriod: orphanGracePeriod,
+			Delete:      deleteOrphanedSubnets,
+		}); err != nil {
+			setupLog.Error(err, "unable to add the orphan sweeper")
+			os.Exit(1)
+		}
+	}
+	if enableAddressSpace {
+		if err = (&controllers.AddressSpaceReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("AddressSpace"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AddressSpace")
+			os.Exit(1)
+		}
+	}
+	if enablePartition {
+		if err = (&controllers.PartitionReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("Partition"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Partition")
+			os.Exit(1)
+		}
+		if enableWebhooks {
+			if err = (&corev1.Partition{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "Partition")
+				os.Exit(1)
+			}
+		}
+	}
+	if enableIPAddressClaim {
+		if !enableLeaderElection {
+			setupLog.Info("leader election is disabled, run a single replica or addresses may be handed out twice")
+		}
+		if err = (&controllers.IPAddressClaimReconciler{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Log:       ctrl.Log.WithName("controllers").WithName("IPAddressClaim"),
+			Scheme:    mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "IPAddressClaim")
+			os.Exit(1)
+		}
+		if enableWebhooks {
+			if err = (&corev1.IPAddressClaim{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "IPAddressClaim")
+				os.Exit(1)
+			}
+		}
+	}
+	if enableDualStackSubnet {
+		if err = (&controllers.DualStackSubnetReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("DualStackSubnet"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DualStackSubnet")
+			os.Exit(1)
+		}
+		if enableWebhooks {
+			if err = (&corev1.DualStackSubnet{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "DualStackSubnet")
+				os.Exit(1)
+			}
+		}
+	}
+	if enableKeaConfig {
+		if err = (&controllers.KeaConfigReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("KeaConfig"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr, options); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "KeaConfig")
+			os.Exit(1)
+		}
+	}
+	// The webhooks of the network.onmetal.de group share the rules of the legacy ones.
+	if enableWebhooks {
+		if err = v1alpha1.SetupWebhooksWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "network.onmetal.de")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")